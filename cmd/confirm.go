@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// confirm asks before an irreversible action such as "delete". With --yes it
+// returns at once; otherwise stdin must be a terminal so that a script never
+// deletes by accident. Anything but y or yes aborts.
+func confirm(cmd *cobra.Command, action, question string, yes bool) error {
+	if yes {
+		return nil
+	}
+	in := cmd.InOrStdin()
+	if f, ok := in.(*os.File); ok && !isTerminal(f) {
+		return fmt.Errorf("refusing to %s without confirmation: stdin is not a terminal\nRe-run with --yes to confirm", action)
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("%s aborted", action)
+}
+
+// isTerminal reports whether f is an interactive terminal. Other character
+// devices such as /dev/null are not.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
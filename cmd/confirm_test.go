@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		yes   bool
+		ok    bool
+	}{
+		{"y\n", false, true},
+		{"YES\n", false, true},
+		{"n\n", false, false},
+		{"\n", false, false},
+		{"", false, false},
+		{"", true, true},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		cmd.SetIn(strings.NewReader(tt.input))
+		stderr := &bytes.Buffer{}
+		cmd.SetErr(stderr)
+		err := confirm(cmd, "delete", "Delete 2 version(s)?", tt.yes)
+		if (err == nil) != tt.ok {
+			t.Errorf("input %q, yes=%v: got err %v", tt.input, tt.yes, err)
+		}
+		if !tt.yes && !strings.Contains(stderr.String(), "[y/N]") {
+			t.Errorf("expected a prompt on stderr, got %q", stderr.String())
+		}
+	}
+}
+
+func TestConfirmRefusesWithoutTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cmd := &cobra.Command{}
+	cmd.SetIn(f)
+	err = confirm(cmd, "delete", "Delete?", false)
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("expected a refusal mentioning --yes, got %v", err)
+	}
+	if err := confirm(cmd, "delete", "Delete?", true); err != nil {
+		t.Errorf("--yes should skip the check: %v", err)
+	}
+}
+
+func TestConfirmRefusesDevNull(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+
+	cmd := &cobra.Command{}
+	cmd.SetIn(f)
+	cmd.SetErr(new(bytes.Buffer))
+	if err := confirm(cmd, "delete", "Delete?", false); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("stdin from %s should be refused, got %v", os.DevNull, err)
+	}
+}
@@ -6,11 +6,11 @@ import (
 	"strings"
 	"time"
 
-	"obsput/pkg/config"
 	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/retention"
 	"obsput/pkg/styled"
 
-	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 )
 
@@ -31,21 +31,36 @@ Examples:
   obsput delete --before 7d
 
   # Delete all versions older than 24 hours
-  obsput delete --before 24h`,
+  obsput delete --before 24h
+
+  # Only versions uploaded with --prefix releases
+  obsput delete v1.0.0-abc123 --prefix releases
+
+  # Keep a copy under .trash/ that "obsput trash restore" can bring back
+  obsput delete v1.0.0-abc123 --soft`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			profile, _ := cmd.Flags().GetString("profile")
 			before, _ := cmd.Flags().GetString("before")
+			prefix, _ := cmd.Flags().GetString("prefix")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			requireFlag, _ := cmd.Flags().GetString("require")
+			failFast, _ := cmd.Flags().GetBool("fail-fast")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			yes, _ := cmd.Flags().GetBool("yes")
+			soft, _ := cmd.Flags().GetBool("soft")
 
-			// Load config
-			cfg, err := config.LoadOrInit()
+			require, err := parseRequire(requireFlag)
 			if err != nil {
-				return fmt.Errorf("load config failed: %v\nRun: obsput obs add --name prod --endpoint \"xxx\" --bucket \"xxx\" --ak \"xxx\" --sk \"xxx\"", err)
+				return err
 			}
 
-			if len(cfg.Configs) == 0 {
-				return fmt.Errorf("no OBS configurations configured.\n\nRun: obsput obs add --name prod --endpoint \"obs.xxx.com\" --bucket \"bucket\" --ak \"xxx\" --sk \"xxx\"")
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
+			}
+			if err := require.check(len(configsToUse)); err != nil {
+				return err
 			}
 
 			// Parse before date
@@ -57,8 +72,11 @@ Examples:
 				}
 			}
 
-			// Create styled output
-			out := styled.NewOutput()
+			// Messages go to stderr, outcomes to stdout
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
 
 			out.Divider()
 			if before != "" {
@@ -74,104 +92,272 @@ Examples:
 			}
 			out.Divider()
 
-			totalDeleted := 0
-			totalFailed := 0
-
-			for name, obsCfg := range cfg.Configs {
-				// If profile specified, only delete from that OBS
-				if profile != "" && name != profile {
+			// Plan: find the versions of every profile before deleting any
+			items := make([]output.DeleteItem, 0)
+			tally := newOutcome(require, len(configsToUse))
+			var plans []deletePlan
+			var protected int
+			for _, name := range sortedProfileNames(configsToUse) {
+				if failFast && tally.failed > 0 {
+					out.WarningMsg(fmt.Sprintf("Skipping %s (--fail-fast)", name))
 					continue
 				}
+				obsCfg := configsToUse[name]
 
 				out.Subsection("[" + name + "]")
 
-				client := obsclient.NewClient(obsCfg.Endpoint, obsCfg.Bucket, obsCfg.AK, obsCfg.SK)
+				client, err := newClient(obsCfg)
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					tally.failure(err)
+					continue
+				}
 
-				// List all versions
-				versions, err := client.ListVersions("")
+				// List version directories; objects are only listed for the
+				// versions that get deleted
+				versions, err := client.ListVersionDirs(prefix)
 				if err != nil {
 					out.ErrorMsg(fmt.Sprintf("Failed to list versions: %v", err))
+					tally.failure(err)
 					continue
 				}
 
 				// Filter versions to delete
-				var toDelete []string
+				var matched []obsclient.VersionDir
 				if before != "" {
 					for _, v := range versions {
-						versionDate, err := parseVersionDate(v.Version)
-						if err == nil && versionDate.Before(beforeTime) {
-							toDelete = append(toDelete, v.Version)
+						versionTime, ok := obsclient.VersionTime(v.Version)
+						if ok && versionTime.Before(beforeTime) {
+							matched = append(matched, v)
 						}
 					}
-				} else if len(args) > 0 {
+				} else {
 					// Delete specific version (prefix match)
 					targetVersion := args[0]
 					for _, v := range versions {
 						if strings.HasPrefix(v.Version, targetVersion) {
-							toDelete = append(toDelete, v.Version)
+							matched = append(matched, v)
 						}
 					}
 				}
 
-				if len(toDelete) == 0 {
+				plan, err := planDelete(client, name, matched, obsCfg.Protect)
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					tally.failure(err)
+					continue
+				}
+				plan.soft = obsCfg.SoftDelete
+				if cmd.Flags().Changed("soft") {
+					plan.soft = soft
+				}
+				for _, p := range plan.protected {
+					protected++
+					items = append(items, output.DeleteItem{Profile: name, Version: p.group.Version, Prefix: p.group.Prefix, Status: "protected", Objects: len(p.group.Files), Size: formatter.FormatSize(p.group.TotalSize), Reason: p.reason})
+					out.Printf(styled.Muted, "    keep    %s (%s)\n", p.group.Prefix, p.reason)
+				}
+				if len(plan.groups) == 0 {
 					out.Println(styled.Muted, "  No versions to delete")
+					tally.success()
 					continue
 				}
+				for _, g := range plan.groups {
+					out.Printf(styled.Warning, "    delete  %s (%d objects, %s)\n", g.Prefix, len(g.Files), formatter.FormatSize(g.TotalSize))
+				}
+				plans = append(plans, plan)
+			}
 
-				// Show versions to delete
-				out.Println(styled.Info, fmt.Sprintf("  Found %d version(s) to delete:", len(toDelete)))
-				for _, v := range toDelete {
-					out.Println(styled.Muted, fmt.Sprintf("    - %s", v))
+			// Show the whole plan before asking
+			var planVersions, planObjects int
+			var planBytes int64
+			for _, plan := range plans {
+				for _, g := range plan.groups {
+					planVersions++
+					planObjects += len(g.Files)
+					planBytes += g.TotalSize
+				}
+			}
+			if planVersions > 0 {
+				out.Section("Plan")
+				out.KeyValue("Profiles", len(plans))
+				out.KeyValue("Versions", planVersions)
+				out.KeyValue("Objects", planObjects)
+				out.KeyValue("Size", formatter.FormatSize(planBytes))
+				if protected > 0 {
+					out.KeyValue("Protected", fmt.Sprintf("%d version(s) kept", protected))
 				}
 				out.Spacer()
+			}
 
-				if dryRun {
-					out.WarningMsg("DRY RUN - Skipping deletion")
+			if dryRun && planVersions > 0 {
+				out.WarningMsg("DRY RUN - Skipping deletion")
+				for _, plan := range plans {
+					for _, g := range plan.groups {
+						items = append(items, output.DeleteItem{Profile: plan.profile, Version: g.Version, Prefix: g.Prefix, Status: "planned", Objects: len(g.Files), Size: formatter.FormatSize(g.TotalSize)})
+					}
+					tally.success()
+				}
+				plans = nil
+			}
+
+			if len(plans) > 0 {
+				question := fmt.Sprintf("Delete %d version(s), %d object(s), %s from %d profile(s)?", planVersions, planObjects, formatter.FormatSize(planBytes), len(plans))
+				if err := confirm(cmd, "delete", question, yes); err != nil {
+					return err
+				}
+			}
+
+			// Delete versions, several at a time
+			now := time.Now()
+			totalDeleted := 0
+			totalFailed := 0
+			for _, plan := range plans {
+				if failFast && tally.failed > 0 {
+					out.WarningMsg(fmt.Sprintf("Skipping %s (--fail-fast)", plan.profile))
 					continue
 				}
+				out.Subsection("[" + plan.profile + "]")
 
-				// Delete versions
-				deleted := 0
-				failed := 0
-				for _, v := range toDelete {
-					result := client.DeleteVersion(v)
+				var profileErrs []error
+				results := deleteVersionGroups(plan.client, plan.groups, concurrency, plan.soft, now)
+				for i, g := range plan.groups {
+					result := results[i]
+					item := output.DeleteItem{Profile: plan.profile, Version: g.Version, Prefix: g.Prefix, Objects: len(result.Deleted), Size: formatter.FormatSize(g.TotalSize)}
 					if result.Success {
-						deleted++
-						out.SuccessMsg(fmt.Sprintf("Deleted: %s", v))
+						totalDeleted++
+						item.Status = "deleted"
+						if plan.soft {
+							item.Status = "trashed"
+							out.SuccessMsg(fmt.Sprintf("Moved to trash: %s", g.Prefix))
+						} else {
+							out.SuccessMsg(fmt.Sprintf("Deleted: %s", g.Prefix))
+						}
 					} else {
-						failed++
-						out.ErrorMsg(fmt.Sprintf("Failed: %s (%s)", v, result.Error))
+						totalFailed++
+						item.Status = "failed"
+						item.Error = result.Error
+						item.Remaining = remainingKeys(result)
+						profileErrs = append(profileErrs, result.Err)
+						out.ErrorMsg(fmt.Sprintf("Failed: %s (%s)", g.Prefix, result.Error))
+						printRemaining(out, result)
 					}
+					items = append(items, item)
+				}
+				if len(profileErrs) > 0 {
+					tally.failure(profileErrs...)
+				} else {
+					tally.success()
 				}
-
-				// Summary for this OBS
-				t := table.NewWriter()
-				t.SetOutputMirror(cmd.OutOrStdout())
-				t.AppendHeader(table.Row{"Metric", "Count"})
-				t.AppendRow(table.Row{"Deleted", deleted})
-				t.AppendRow(table.Row{"Failed", failed})
-				t.Render()
-
-				totalDeleted += deleted
-				totalFailed += failed
 				out.Divider()
 			}
 
 			// Final summary
-			if before != "" && !dryRun {
+			if len(plans) > 0 {
 				out.Section("Summary")
 				out.Summary(totalDeleted, totalFailed)
 			}
+			out.Spacer()
 
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			if err := tally.err("delete"); err != nil {
+				return err
+			}
+			if before == "" && len(items) == 0 {
+				return fmt.Errorf("version %s not found", args[0])
+			}
+			if before == "" && protected > 0 && planVersions == 0 {
+				return fmt.Errorf("version %s is protected and was not deleted", args[0])
+			}
 			return nil
 		},
 	}
 	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
 	cmd.Flags().String("before", "", "Delete versions before this date (YYYY-MM-DD or Nd, Nh)")
+	cmd.Flags().String("prefix", "", "Only versions uploaded with this prefix (default: any prefix)")
 	cmd.Flags().Bool("dry-run", false, "Show what would be deleted without actually deleting")
+	cmd.Flags().String("require", "all", "Profiles that must succeed: all, any or a number")
+	cmd.Flags().Bool("fail-fast", false, "Skip the remaining profiles after one fails")
+	cmd.Flags().Int("concurrency", obsclient.DefaultDeleteConcurrency, "Number of versions to delete at once")
+	cmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation (required when not interactive)")
+	cmd.Flags().Bool("soft", false, "Move versions to the trash instead of deleting them (default: the profile's soft_delete)")
 	return cmd
 }
 
+// deleteVersionGroups deletes the listed objects of versions, or moves them
+// to the trash when soft
+func deleteVersionGroups(client *obsclient.Client, groups []obsclient.VersionGroup, concurrency int, soft bool, now time.Time) []*obsclient.DeleteResult {
+	if soft {
+		return client.TrashVersionGroups(groups, concurrency, now)
+	}
+	return client.DeleteVersionGroups(groups, concurrency)
+}
+
+// deletePlan holds the versions of one profile that delete will remove
+type deletePlan struct {
+	profile string
+	client  *obsclient.Client
+	// groups holds the versions with the objects listed for the plan; only
+	// those objects are deleted
+	groups    []obsclient.VersionGroup
+	protected []protectedVersion
+	// soft moves the versions to the trash
+	soft bool
+}
+
+// protectedVersion is a matched version the protection list keeps
+type protectedVersion struct {
+	group  obsclient.VersionGroup
+	reason string
+}
+
+// planDelete lists the objects of each matched version, for counts and
+// sizes, and sets aside the versions the protection list keeps
+func planDelete(client *obsclient.Client, profile string, dirs []obsclient.VersionDir, protect *retention.Protection) (deletePlan, error) {
+	plan := deletePlan{profile: profile, client: client}
+	for _, dir := range dirs {
+		files, err := client.ListVersionFiles(dir)
+		if err != nil {
+			return plan, fmt.Errorf("failed to list files of %s: %w", dir.Prefix, err)
+		}
+		g := obsclient.VersionGroup{Version: dir.Version, Prefix: dir.Prefix, Files: files}
+		for _, f := range files {
+			g.TotalSize += f.SizeBytes
+		}
+		reason, err := protect.Protects(g, client.VersionTag)
+		if err != nil {
+			return plan, err
+		}
+		if reason != "" {
+			plan.protected = append(plan.protected, protectedVersion{group: g, reason: reason})
+			continue
+		}
+		plan.groups = append(plan.groups, g)
+	}
+	return plan, nil
+}
+
+// remainingKeys lists the keys a failed delete left behind
+func remainingKeys(result *obsclient.DeleteResult) []string {
+	keys := make([]string, 0, len(result.Failed))
+	for _, f := range result.Failed {
+		keys = append(keys, f.Key)
+	}
+	return keys
+}
+
+// printRemaining shows each key a failed delete left behind and why
+func printRemaining(out *styled.Output, result *obsclient.DeleteResult) {
+	for _, f := range result.Failed {
+		reason := f.Error
+		if f.Code != "" {
+			reason = f.Code + ": " + f.Error
+		}
+		out.Println(styled.Muted, fmt.Sprintf("    remaining: %s (%s)", f.Key, reason))
+	}
+}
+
 // parseBeforeDate parses date string like "2026-01-01" or "7d" or "24h"
 func parseBeforeDate(s string) (time.Time, error) {
 	// Try date format YYYY-MM-DD
@@ -196,21 +382,4 @@ func parseBeforeDate(s string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("invalid format: %s", s)
 }
 
-// parseVersionDate extracts date from version string
-// Format: v1.0.0-commit-YYYYMMDD-HHMMSS-counter
-func parseVersionDate(version string) (time.Time, error) {
-	parts := strings.Split(version, "-")
-	if len(parts) < 4 {
-		return time.Time{}, fmt.Errorf("invalid version format")
-	}
-
-	// parts[2] is date like "20260214"
-	dateStr := parts[2]
-	if len(dateStr) != 8 {
-		return time.Time{}, fmt.Errorf("invalid date in version")
-	}
-
-	return time.Parse("20060102", dateStr)
-}
-
 func init() {}
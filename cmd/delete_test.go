@@ -21,3 +21,22 @@ func TestDeleteCommandExecution(t *testing.T) {
 		t.Fatalf("execute delete --help failed: %v", err)
 	}
 }
+
+func TestDeleteCommandFlags(t *testing.T) {
+	cmd := NewDeleteCommand()
+	for _, name := range []string{"prefix", "concurrency", "before", "dry-run"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected --%s flag", name)
+		}
+	}
+}
+
+func TestDeleteYesFlag(t *testing.T) {
+	cmd := NewDeleteCommand()
+	if cmd.Flags().Lookup("yes") == nil {
+		t.Fatal("expected --yes flag")
+	}
+	if cmd.Flags().ShorthandLookup("y") == nil {
+		t.Error("expected -y shorthand")
+	}
+}
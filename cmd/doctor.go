@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/styled"
+
+	"github.com/spf13/cobra"
+)
+
+func NewDoctorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor [profile]",
+		Short: "Diagnose connectivity, credentials and permissions",
+		Long: `Check what an upload needs, one step at a time:
+
+  dns          the endpoint host resolves
+  tcp          the endpoint port accepts connections
+  tls          the TLS handshake succeeds and the certificate is trusted
+  clock        the local clock is within 15 minutes of the server
+  credentials  the access key and secret key are accepted
+  bucket       the bucket exists and is reachable
+  list, put, get, delete
+               the keys may list the bucket and write, read and delete a
+               temporary object under ` + obsclient.DoctorProbePrefix + `
+
+A failed check skips the checks that depend on it. Each failure comes with a
+hint on how to fix it.
+
+Examples:
+  obsput doctor
+  obsput doctor prod -o json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile := ""
+			if len(args) > 0 {
+				profile = args[0]
+			}
+
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
+			}
+
+			// Messages go to stderr, checks to stdout
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			out.Divider()
+			out.Section("Doctor")
+			out.Divider()
+
+			items := make([]output.DoctorItem, 0)
+			var failures []obsclient.ErrorKind
+			failedProfiles := 0
+			for _, name := range sortedProfileNames(configsToUse) {
+				out.Subsection("[" + name + "]")
+
+				client, err := newClient(configsToUse[name])
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					failures = append(failures, obsclient.ErrorOther)
+					failedProfiles++
+					continue
+				}
+
+				failed := false
+				for _, check := range client.Diagnose() {
+					out.Printf(checkStyle(check.Status), "  %-12s %-5s %s\n", check.Name, check.Status, check.Detail)
+					if check.Hint != "" {
+						out.Printf(styled.Muted, "  %-12s       %s\n", "", check.Hint)
+					}
+					if check.Failed() {
+						failed = true
+						failures = append(failures, obsclient.Classify(check.Err))
+					}
+					items = append(items, output.DoctorItem{
+						Profile: name,
+						Check:   check.Name,
+						Status:  check.Status,
+						Detail:  check.Detail,
+						Hint:    check.Hint,
+					})
+				}
+				if failed {
+					failedProfiles++
+				}
+			}
+			out.Spacer()
+
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			if failedProfiles > 0 {
+				return withExitCode(kindExitCode(worstKind(failures)), fmt.Errorf("%d of %d profile(s) failed the checks", failedProfiles, len(configsToUse)))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+// checkStyle colours a check by its status
+func checkStyle(status string) styled.Style {
+	switch status {
+	case obsclient.CheckPass:
+		return styled.Success
+	case obsclient.CheckWarn:
+		return styled.Warning
+	case obsclient.CheckFail:
+		return styled.Error
+	}
+	return styled.Muted
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/styled"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	cmd := NewDoctorCommand()
+	if cmd.Use != "doctor [profile]" {
+		t.Errorf("expected use 'doctor [profile]', got '%s'", cmd.Use)
+	}
+	if err := cmd.Args(cmd, []string{"a", "b"}); err == nil {
+		t.Error("doctor should take at most one profile")
+	}
+}
+
+func TestCheckStyle(t *testing.T) {
+	if checkStyle(obsclient.CheckFail) != styled.Error || checkStyle(obsclient.CheckPass) != styled.Success {
+		t.Error("pass and fail should be styled as success and error")
+	}
+	if checkStyle(obsclient.CheckSkip) != styled.Muted {
+		t.Error("skipped checks should be muted")
+	}
+}
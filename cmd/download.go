@@ -5,6 +5,8 @@ import (
 
 	"obsput/pkg/config"
 	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/platform"
 	"obsput/pkg/styled"
 
 	"github.com/spf13/cobra"
@@ -14,66 +16,140 @@ func NewDownloadCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "download <version>",
 		Short: "Show download commands for a version",
-		Args:  cobra.ExactArgs(1),
+		Long: `Show download commands for the files of a version.
+
+With --object-version the argument is an object key instead, and the
+commands fetch that stored copy of it from a bucket with versioning enabled
+(see "obsput list --history <key>").
+
+Examples:
+  obsput download v1.0.0-abc123-20260214-153045-1 --platform auto
+  obsput download v1.0.0-abc123-20260214-153045-1/app-linux-amd64 --profile prod --object-version <id>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			version := args[0]
 			profile, _ := cmd.Flags().GetString("profile")
+			platformFlag, _ := cmd.Flags().GetString("platform")
+			prefix, _ := cmd.Flags().GetString("prefix")
+			objectVersion, _ := cmd.Flags().GetString("object-version")
+			requireFlag, _ := cmd.Flags().GetString("require")
 
-			// Load config
-			cfg, err := config.LoadOrInit()
+			require, err := parseRequire(requireFlag)
 			if err != nil {
-				return fmt.Errorf("load config failed: %v\nRun: obsput obs add --name prod --endpoint \"xxx\" --bucket \"xxx\" --ak \"xxx\" --sk \"xxx\"", err)
+				return err
 			}
 
-			if len(cfg.Configs) == 0 {
-				return fmt.Errorf("no OBS configurations configured.\n\nRun: obsput obs add --name prod --endpoint \"obs.xxx.com\" --bucket \"bucket\" --ak \"xxx\" --sk \"xxx\"")
+			var want platform.Platform
+			if platformFlag != "" {
+				p, err := platform.Parse(platformFlag)
+				if err != nil {
+					return err
+				}
+				want = p
 			}
 
-			// Determine which configs to use
-			var configsToUse map[string]*config.OBS
-			if profile != "" {
-				obsCfg := cfg.GetOBS(profile)
-				if obsCfg == nil {
-					return fmt.Errorf("profile '%s' not found in config.\n\nRun: obsput obs list", profile)
-				}
-				configsToUse = map[string]*config.OBS{
-					profile: obsCfg,
-				}
-			} else {
-				configsToUse = cfg.Configs
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
+			}
+			if err := require.check(len(configsToUse)); err != nil {
+				return err
+			}
+
+			// Messages go to stderr, file details to stdout
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
 			}
 
-			// Create styled output
-			out := styled.NewOutput()
+			if objectVersion != "" {
+				return downloadObjectVersion(out, formatter, configsToUse, require, version, objectVersion)
+			}
 
 			out.Divider()
 			out.Section("Download")
 			out.KeyValue("Version", version)
+			if prefix != "" {
+				out.KeyValue("Prefix", prefix)
+			}
+			if !want.IsZero() {
+				out.KeyValue("Platform", want.String())
+			}
 			out.Divider()
 
-			found := false
-			for name, obsCfg := range configsToUse {
+			// A profile succeeds when it could be searched, whether or not it
+			// holds the version
+			items := make([]output.DownloadItem, 0)
+			tally := newOutcome(require, len(configsToUse))
+			for _, name := range sortedProfileNames(configsToUse) {
+				obsCfg := configsToUse[name]
 				out.Subsection("[" + name + "]")
 
-				client := obsclient.NewClient(obsCfg.Endpoint, obsCfg.Bucket, obsCfg.AK, obsCfg.SK)
+				client, err := newClient(obsCfg)
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					tally.failure(err)
+					continue
+				}
 
-				// Find the version
-				versions, err := client.ListVersions("")
+				// Find the version, then list only its files
+				dirs, err := client.ListVersionDirs(prefix)
 				if err != nil {
 					out.ErrorMsg(fmt.Sprintf("Failed to list versions: %v", err))
+					tally.failure(err)
 					continue
 				}
 
-				for _, v := range versions {
+				var versions []obsclient.VersionInfo
+				var profileErrs []error
+				for _, d := range dirs {
+					if d.Version != version {
+						continue
+					}
+					files, err := client.ListVersionFiles(d)
+					if err != nil {
+						out.ErrorMsg(fmt.Sprintf("Failed to list files of %s: %v", d.Prefix, err))
+						profileErrs = append(profileErrs, err)
+						continue
+					}
+					versions = append(versions, files...)
+				}
+
+				// The platform may only be in the metadata put stored
+				platforms := make([]string, len(versions))
+				for i, v := range versions {
+					p, ok, err := client.FilePlatform(v)
+					if err != nil {
+						out.ErrorMsg(fmt.Sprintf("Failed to read the platform of %s: %v", v.Key, err))
+						profileErrs = append(profileErrs, err)
+						continue
+					}
+					if ok {
+						platforms[i] = p.String()
+					}
+				}
+				if len(profileErrs) > 0 {
+					tally.failure(profileErrs...)
+				} else {
+					tally.success()
+				}
+
+				for i, v := range versions {
 					if v.Version == version {
-						found = true
+						if !want.IsZero() && platforms[i] != want.String() {
+							continue
+						}
 						cleanURL := obsclient.CleanURL(v.URL)
-						client := obsclient.NewClient(obsCfg.Endpoint, obsCfg.Bucket, obsCfg.AK, obsCfg.SK)
 						filename := client.ExtractFilenameFromKey(v.Key)
-						out.KeyValue("Version", v.Version)
-						out.KeyValue("URL", cleanURL)
-						out.KeyValue("Size", v.Size)
-						out.Divider()
+						item := output.DownloadItem{
+							Profile:  name,
+							Version:  v.Version,
+							Filename: filename,
+							Size:     v.Size,
+							URL:      cleanURL,
+							Platform: platforms[i],
+						}
+						items = append(items, item)
 						out.Println(styled.Header, "Download Commands:")
 						out.Printf(styled.Muted, "  curl -k -o %s %s\n", filename, cleanURL)
 						out.Printf(styled.Muted, "  wget --no-check-certificate -O %s %s\n", filename, cleanURL)
@@ -81,15 +157,97 @@ func NewDownloadCommand() *cobra.Command {
 				}
 			}
 
-			if !found {
-				out.WarningMsg(fmt.Sprintf("Version %s not found", version))
-			}
+			out.Spacer()
 
-			return nil
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			// A failed lookup explains a missing version better than "not found"
+			if err := tally.err("lookup of " + version); err != nil {
+				return err
+			}
+			if len(items) > 0 {
+				return nil
+			}
+			if !want.IsZero() {
+				return fmt.Errorf("version %s has no file for %s", version, want)
+			}
+			return fmt.Errorf("version %s not found", version)
 		},
 	}
 	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
+	cmd.Flags().String("prefix", "", "Only the version uploaded with this prefix (default: any prefix)")
+	cmd.Flags().String("platform", "", "Only show the file for this platform (os/arch, or auto for this host)")
+	cmd.Flags().String("object-version", "", "Fetch this stored copy of the object key given as argument")
+	cmd.Flags().String("require", "all", "Profiles that must be searched successfully: all, any or a number")
 	return cmd
 }
 
+// downloadObjectVersion shows download commands for one stored copy of key
+func downloadObjectVersion(out *styled.Output, formatter *output.Formatter, configsToUse map[string]*config.OBS, require requirement, key, versionID string) error {
+	out.Divider()
+	out.Section("Download")
+	out.KeyValue("Key", key)
+	out.KeyValue("Object version", versionID)
+	out.Divider()
+
+	items := make([]output.DownloadItem, 0)
+	tally := newOutcome(require, len(configsToUse))
+	for _, name := range sortedProfileNames(configsToUse) {
+		out.Subsection("[" + name + "]")
+
+		client, err := newClient(configsToUse[name])
+		if err != nil {
+			out.ErrorMsg(err.Error())
+			tally.failure(err)
+			continue
+		}
+
+		v, err := client.GetObjectVersion(key, versionID)
+		if err != nil {
+			if obsclient.IsNotFound(err) {
+				out.Println(styled.Muted, "  Not found")
+				tally.success()
+				continue
+			}
+			out.ErrorMsg(err.Error())
+			tally.failure(err)
+			continue
+		}
+		url, err := client.GetSignedVersionURL(key, versionID, 24)
+		if err != nil {
+			out.ErrorMsg(fmt.Sprintf("Failed to sign URL: %v", err))
+			tally.failure(err)
+			continue
+		}
+		tally.success()
+
+		filename := client.ExtractFilenameFromKey(key)
+		items = append(items, output.DownloadItem{
+			Profile:  name,
+			Version:  versionID,
+			Filename: filename,
+			Size:     formatter.FormatSize(v.Size),
+			URL:      url,
+		})
+		// The signed query string selects the version, so the URL is quoted
+		out.Println(styled.Header, "Download Commands:")
+		out.Printf(styled.Muted, "  curl -k -o %s '%s'\n", filename, url)
+		out.Printf(styled.Muted, "  wget --no-check-certificate -O %s '%s'\n", filename, url)
+	}
+
+	out.Spacer()
+
+	if err := formatter.Render(items); err != nil {
+		return err
+	}
+	if err := tally.err("lookup of object version " + versionID); err != nil {
+		return err
+	}
+	if len(items) > 0 {
+		return nil
+	}
+	return fmt.Errorf("object version %s of %s not found\n\nRun: obsput list --history %s", versionID, key, key)
+}
+
 func init() {}
@@ -2,7 +2,13 @@ package cmd
 
 import (
 	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"obsput/pkg/config"
 )
 
 func TestDownloadCommand(t *testing.T) {
@@ -21,3 +27,50 @@ func TestDownloadCommandExecution(t *testing.T) {
 		t.Fatalf("execute download --help failed: %v", err)
 	}
 }
+
+func TestDownloadCommandPrefixFlag(t *testing.T) {
+	cmd := NewDownloadCommand()
+	if cmd.Flags().Lookup("prefix") == nil {
+		t.Error("expected --prefix flag")
+	}
+}
+
+func TestDownloadRequire(t *testing.T) {
+	t.Cleanup(func() { config.UseEnv(false) })
+	t.Setenv(config.EnvProfile, "")
+	t.Setenv(config.EnvBucket, "bucket")
+	t.Setenv(config.EnvAccessKey, "ak")
+	t.Setenv(config.EnvSecretKey, "sk")
+	t.Setenv("OBSPUT_ENV_AK", "")
+	t.Setenv("OBSPUT_ENV_SK", "")
+
+	run := func(args ...string) error {
+		root := NewRootCommand()
+		root.SetOut(new(bytes.Buffer))
+		root.SetErr(new(bytes.Buffer))
+		root.SetArgs(append([]string{"--profile-from-env", "download", "v1.0.0-abc-20260101-120000-1"}, args...))
+		return root.Execute()
+	}
+
+	if err := run("--require", "2"); exitCode(err) != ExitConfig {
+		t.Errorf("--require 2 with one profile: exit code %d, want %d (%v)", exitCode(err), ExitConfig, err)
+	}
+
+	// A profile that cannot be searched fails the lookup, not "not found"
+	t.Setenv(config.EnvEndpoint, "http://127.0.0.1:1")
+	err := run()
+	if exitCode(err) != ExitNetwork || err == nil || !strings.Contains(err.Error(), "--require all") {
+		t.Errorf("unreachable profile: exit code %d, want %d (%v)", exitCode(err), ExitNetwork, err)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		io.WriteString(w, `<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated></ListBucketResult>`)
+	}))
+	defer empty.Close()
+	t.Setenv(config.EnvEndpoint, empty.URL)
+	err = run()
+	if exitCode(err) != ExitFailure || err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("searched profile without the version: exit code %d, want %d (%v)", exitCode(err), ExitFailure, err)
+	}
+}
@@ -108,7 +108,7 @@ func TestE2E_DeleteFromMinIO(t *testing.T) {
 	}
 
 	// Run delete
-	deleteCmd := exec.Command(filepath.Join(tmpDir, "obsput"), "delete", version)
+	deleteCmd := exec.Command(filepath.Join(tmpDir, "obsput"), "delete", version, "--yes")
 	deleteCmd.Env = append(os.Environ(),
 		"HOME="+tmpDir,
 	)
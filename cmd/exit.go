@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	obsclient "obsput/pkg/obs"
+)
+
+// Exit codes returned by obsput
+const (
+	ExitOK = 0
+	// ExitFailure is any failure without a more specific code
+	ExitFailure = 1
+	// ExitConfig means the config file or profile selection is invalid
+	ExitConfig = 2
+	// ExitAuth means the credentials were rejected
+	ExitAuth = 3
+	// ExitNetwork means an endpoint could not be reached
+	ExitNetwork = 4
+	// ExitPartial means some profiles succeeded but not as many as --require asks
+	ExitPartial = 5
+)
+
+// exitError carries the process exit code for an error
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode attaches an exit code to err
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// configError marks err as a config problem
+func configError(err error) error {
+	return withExitCode(ExitConfig, err)
+}
+
+// exitCode returns the exit code for an error returned by a command
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return ExitFailure
+}
+
+// tokenExpiredHint explains an expired or invalid security token
+const tokenExpiredHint = "The security token expired or is invalid. Renew the temporary credentials " +
+	"(security_token, OBSPUT_<PROFILE>_SECURITY_TOKEN, OBS_SECURITY_TOKEN or credential_process) and run the command again."
+
+// errorHint suggests how to fix err, or returns ""
+func errorHint(err error) string {
+	if obsclient.IsTokenExpired(err) {
+		return tokenExpiredHint
+	}
+	return ""
+}
+
+// kindExitCode maps a failure kind to its exit code
+func kindExitCode(kind obsclient.ErrorKind) int {
+	switch kind {
+	case obsclient.ErrorAuth:
+		return ExitAuth
+	case obsclient.ErrorNetwork:
+		return ExitNetwork
+	}
+	return ExitFailure
+}
+
+// requirement is the --require policy: how many profiles must succeed
+type requirement struct {
+	// all requires every selected profile; otherwise count applies
+	all   bool
+	count int
+}
+
+// parseRequire parses "all", "any" or a number of profiles
+func parseRequire(s string) (requirement, error) {
+	switch s {
+	case "", "all":
+		return requirement{all: true}, nil
+	case "any":
+		return requirement{count: 1}, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return requirement{}, fmt.Errorf("invalid --require %q (use all, any or a number of profiles)", s)
+	}
+	return requirement{count: n}, nil
+}
+
+// check rejects a count larger than the number of selected profiles
+func (r requirement) check(profiles int) error {
+	if !r.all && r.count > profiles {
+		return configError(fmt.Errorf("--require %d but only %d profile(s) selected", r.count, profiles))
+	}
+	return nil
+}
+
+func (r requirement) String() string {
+	if r.all {
+		return "all"
+	}
+	if r.count == 1 {
+		return "any"
+	}
+	return strconv.Itoa(r.count)
+}
+
+// outcome tallies per-profile results and turns them into the command's
+// error under a --require policy
+type outcome struct {
+	require   requirement
+	total     int
+	succeeded int
+	failed    int
+	kinds     []obsclient.ErrorKind
+	// hints collects errorHint of the failures, each once
+	hints []string
+}
+
+func newOutcome(require requirement, total int) *outcome {
+	return &outcome{require: require, total: total}
+}
+
+// success records a profile that completed
+func (o *outcome) success() {
+	o.succeeded++
+}
+
+// failure records a profile that failed, with the errors it hit
+func (o *outcome) failure(errs ...error) {
+	o.failed++
+	for _, err := range errs {
+		o.kinds = append(o.kinds, obsclient.Classify(err))
+		if hint := errorHint(err); hint != "" && !containsHint(o.hints, hint) {
+			o.hints = append(o.hints, hint)
+		}
+	}
+}
+
+// met reports whether enough profiles succeeded
+func (o *outcome) met() bool {
+	if o.require.all {
+		return o.succeeded == o.total
+	}
+	return o.succeeded >= o.require.count
+}
+
+// err returns nil when the requirement is met. Otherwise the exit code is
+// ExitPartial if any profile succeeded, else the auth or network code when
+// such a failure occurred.
+func (o *outcome) err(action string) error {
+	if o.met() {
+		return nil
+	}
+	msg := fmt.Sprintf("%s succeeded for %d of %d profile(s) (--require %s)", action, o.succeeded, o.total, o.require)
+	for _, hint := range o.hints {
+		msg += "\n\n" + hint
+	}
+	err := errors.New(msg)
+	if o.succeeded > 0 {
+		return withExitCode(ExitPartial, err)
+	}
+	return withExitCode(kindExitCode(worstKind(o.kinds)), err)
+}
+
+func containsHint(hints []string, hint string) bool {
+	for _, h := range hints {
+		if h == hint {
+			return true
+		}
+	}
+	return false
+}
+
+// worstKind picks the kind that decides the exit code: auth, then network
+func worstKind(kinds []obsclient.ErrorKind) obsclient.ErrorKind {
+	kind := obsclient.ErrorOther
+	for _, k := range kinds {
+		if k > kind {
+			kind = k
+		}
+	}
+	return kind
+}
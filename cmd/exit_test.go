@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	obsclient "obsput/pkg/obs"
+)
+
+func TestParseRequire(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    requirement
+		wantErr bool
+	}{
+		{"", requirement{all: true}, false},
+		{"all", requirement{all: true}, false},
+		{"any", requirement{count: 1}, false},
+		{"2", requirement{count: 2}, false},
+		{"0", requirement{}, true},
+		{"most", requirement{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRequire(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRequire(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRequire(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	if err := (requirement{count: 3}).check(2); exitCode(err) != ExitConfig {
+		t.Errorf("--require 3 with 2 profiles should be a config error, got %v", err)
+	}
+}
+
+func TestOutcomeExitCodes(t *testing.T) {
+	netErr := &obsclient.ConnectError{Endpoint: "x", Err: errors.New("refused")}
+
+	tests := []struct {
+		name      string
+		require   string
+		succeeded int
+		failures  []error
+		want      int
+	}{
+		{"all succeeded", "all", 2, nil, ExitOK},
+		{"partial", "all", 1, []error{errors.New("boom")}, ExitPartial},
+		{"any tolerates failure", "any", 1, []error{errors.New("boom")}, ExitOK},
+		{"count not reached", "2", 1, []error{errors.New("boom")}, ExitPartial},
+		{"all failed", "all", 0, []error{errors.New("boom"), errors.New("boom")}, ExitFailure},
+		{"network", "all", 0, []error{netErr, errors.New("boom")}, ExitNetwork},
+	}
+	for _, tt := range tests {
+		require, _ := parseRequire(tt.require)
+		o := newOutcome(require, tt.succeeded+len(tt.failures))
+		for i := 0; i < tt.succeeded; i++ {
+			o.success()
+		}
+		for _, err := range tt.failures {
+			o.failure(err)
+		}
+		if got := exitCode(o.err("upload")); got != tt.want {
+			t.Errorf("%s: exit code = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if exitCode(nil) != ExitOK {
+		t.Error("nil error should exit 0")
+	}
+	if exitCode(errors.New("x")) != ExitFailure {
+		t.Error("plain errors should exit 1")
+	}
+	wrapped := fmt.Errorf("context: %w", configError(errors.New("bad config")))
+	if exitCode(wrapped) != ExitConfig {
+		t.Error("wrapped config errors should keep their exit code")
+	}
+}
+
+func TestOutcomeHintsExpiredToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "<Error><Code>ExpiredToken</Code><Message>The provided token has expired.</Message></Error>")
+	}))
+	defer server.Close()
+	_, tokenErr := obsclient.NewClient(server.URL, "bucket", "ak", "sk").ListVersionDirs("")
+	if errorHint(tokenErr) != tokenExpiredHint {
+		t.Fatalf("no hint for %v", tokenErr)
+	}
+
+	o := newOutcome(requirement{all: true}, 2)
+	o.failure(tokenErr)
+	o.failure(tokenErr)
+	err := o.err("upload")
+	if exitCode(err) != ExitAuth {
+		t.Errorf("exit code = %d, want %d", exitCode(err), ExitAuth)
+	}
+	if strings.Count(err.Error(), tokenExpiredHint) != 1 {
+		t.Errorf("expected the hint once, got %q", err)
+	}
+}
@@ -80,11 +80,14 @@ func TestIntegration_OBSCommandStructure(t *testing.T) {
 
 	// Check expected subcommands exist
 	expectedSubCmds := map[string]bool{
-		"add":    true,
-		"list":   true,
-		"get":    true,
-		"remove": true,
-		"mb":     true,
+		"add":        true,
+		"list":       true,
+		"get":        true,
+		"remove":     true,
+		"mb":         true,
+		"rb":         true,
+		"lifecycle":  true,
+		"versioning": true,
 	}
 
 	for _, c := range subCmds {
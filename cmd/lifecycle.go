@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/styled"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func NewOBSLifecycleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lifecycle",
+		Short: "Manage bucket lifecycle rules",
+		Long: `Let OBS expire or tier objects by prefix instead of running cleanup jobs.
+
+A rule file holds the complete rule set:
+
+  rules:
+    - id: nightly
+      prefix: nightly/
+      warm_days: 7             # move to WARM after 7 days
+      cold_days: 30            # move to COLD after 30 days
+      expire_days: 90          # delete after 90 days
+    - id: uploads
+      prefix: ""
+      abort_multipart_days: 3  # abort multipart uploads left for 3 days`,
+	}
+	cmd.AddCommand(NewOBSLifecycleGetCommand())
+	cmd.AddCommand(NewOBSLifecycleSetCommand())
+	cmd.AddCommand(NewOBSLifecycleDeleteCommand())
+	return cmd
+}
+
+func NewOBSLifecycleGetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <profile>",
+		Short: "Show the lifecycle rules of a profile's bucket",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			client, err := profileClient(name)
+			if err != nil {
+				return err
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			rules, err := client.GetLifecycle()
+			if err != nil {
+				return withExitCode(kindExitCode(obsclient.Classify(err)), fmt.Errorf("get lifecycle of %s failed: %w", name, err))
+			}
+			if len(rules) == 0 {
+				out.Println(styled.Muted, "No lifecycle rules")
+			}
+
+			items := make([]output.LifecycleItem, 0, len(rules))
+			for _, r := range rules {
+				items = append(items, lifecycleItem(name, r))
+			}
+			return formatter.Render(items)
+		},
+	}
+	return cmd
+}
+
+func NewOBSLifecycleSetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <profile>",
+		Short: "Set lifecycle rules from a file or flags",
+		Long: `Set lifecycle rules on a profile's bucket.
+
+With --file the rules in the file replace all rules of the bucket. With
+flags, one rule is added, or replaces the existing rule with the same id;
+the other rules are kept as they are.
+
+Examples:
+  # Replace all rules
+  obsput obs lifecycle set prod --file lifecycle.yaml
+
+  # Expire nightly builds after 30 days
+  obsput obs lifecycle set prod --id nightly --prefix nightly/ --expire-days 30
+
+  # Abort incomplete multipart uploads after 7 days, bucket-wide
+  obsput obs lifecycle set prod --id uploads --abort-multipart-days 7`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			file, _ := cmd.Flags().GetString("file")
+
+			var rules []obsclient.LifecycleRule
+			var rule obsclient.LifecycleRule
+			if file != "" {
+				loaded, err := loadLifecycleRules(file)
+				if err != nil {
+					return configError(err)
+				}
+				rules = loaded
+			} else {
+				rule = lifecycleRuleFromFlags(cmd)
+				if err := rule.Validate(); err != nil {
+					return configError(err)
+				}
+			}
+
+			client, err := profileClient(name)
+			if err != nil {
+				return err
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			// A rule from flags only replaces the rule with its id, leaving
+			// the others as they are on the bucket
+			if file == "" {
+				rules, err = client.SetLifecycleRule(rule)
+			} else {
+				err = client.SetLifecycle(rules)
+			}
+			if err != nil {
+				return withExitCode(kindExitCode(obsclient.Classify(err)), fmt.Errorf("set lifecycle of %s failed: %w", name, err))
+			}
+
+			items := make([]output.LifecycleItem, 0, len(rules))
+			for _, r := range rules {
+				out.SuccessMsg(r.String())
+				items = append(items, lifecycleItem(name, r))
+			}
+			return formatter.Render(items)
+		},
+	}
+	cmd.Flags().StringP("file", "f", "", "YAML file with the complete rule set")
+	cmd.Flags().String("id", "", "Rule id (default: derived from the prefix)")
+	cmd.Flags().String("prefix", "", "Objects the rule applies to (default: the whole bucket)")
+	cmd.Flags().Int("warm-days", 0, "Move objects to WARM storage after N days")
+	cmd.Flags().Int("cold-days", 0, "Move objects to COLD storage after N days")
+	cmd.Flags().Int("expire-days", 0, "Delete objects after N days")
+	cmd.Flags().Int("abort-multipart-days", 0, "Abort incomplete multipart uploads after N days")
+	cmd.Flags().Bool("disabled", false, "Store the rule without applying it")
+	return cmd
+}
+
+func NewOBSLifecycleDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete <profile>",
+		Short: "Remove all lifecycle rules from a profile's bucket",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			client, err := profileClient(name)
+			if err != nil {
+				return err
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteLifecycle(); err != nil {
+				return withExitCode(kindExitCode(obsclient.Classify(err)), fmt.Errorf("delete lifecycle of %s failed: %w", name, err))
+			}
+			out.SuccessMsg(fmt.Sprintf("Removed lifecycle rules of %s", name))
+			// No rules are left
+			return formatter.Render([]output.LifecycleItem{})
+		},
+	}
+	return cmd
+}
+
+// loadLifecycleRules reads a rule file
+func loadLifecycleRules(path string) ([]obsclient.LifecycleRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules []obsclient.LifecycleRule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("%s has no rules", path)
+	}
+	if err := obsclient.ValidateLifecycleRules(file.Rules); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return file.Rules, nil
+}
+
+// lifecycleRuleFromFlags builds the rule given by set's flags
+func lifecycleRuleFromFlags(cmd *cobra.Command) obsclient.LifecycleRule {
+	var r obsclient.LifecycleRule
+	r.ID, _ = cmd.Flags().GetString("id")
+	r.Prefix, _ = cmd.Flags().GetString("prefix")
+	r.WarmDays, _ = cmd.Flags().GetInt("warm-days")
+	r.ColdDays, _ = cmd.Flags().GetInt("cold-days")
+	r.ExpireDays, _ = cmd.Flags().GetInt("expire-days")
+	r.AbortMultipartDays, _ = cmd.Flags().GetInt("abort-multipart-days")
+	r.Disabled, _ = cmd.Flags().GetBool("disabled")
+	if r.ID == "" {
+		r.ID = lifecycleRuleID(r.Prefix)
+	}
+	return r
+}
+
+// lifecycleRuleID derives a rule id from a prefix, e.g. "nightly/" -> "nightly"
+func lifecycleRuleID(prefix string) string {
+	id := make([]rune, 0, len(prefix))
+	for _, r := range prefix {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			id = append(id, r)
+		case len(id) > 0 && id[len(id)-1] != '-':
+			id = append(id, '-')
+		}
+	}
+	for len(id) > 0 && id[len(id)-1] == '-' {
+		id = id[:len(id)-1]
+	}
+	if len(id) == 0 {
+		return "bucket"
+	}
+	return string(id)
+}
+
+// lifecycleItem converts a rule for output
+func lifecycleItem(profile string, r obsclient.LifecycleRule) output.LifecycleItem {
+	status := "enabled"
+	if r.Disabled {
+		status = "disabled"
+	}
+	return output.LifecycleItem{
+		Profile:            profile,
+		ID:                 r.ID,
+		Prefix:             r.Prefix,
+		Status:             status,
+		WarmDays:           r.WarmDays,
+		ColdDays:           r.ColdDays,
+		ExpireDays:         r.ExpireDays,
+		AbortMultipartDays: r.AbortMultipartDays,
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"obsput/pkg/config"
+)
+
+func TestOBSLifecycleCommand(t *testing.T) {
+	cmd := NewOBSLifecycleCommand()
+	want := map[string]bool{"get": true, "set": true, "delete": true}
+	for _, c := range cmd.Commands() {
+		delete(want, c.Name())
+	}
+	if len(want) != 0 {
+		t.Errorf("missing lifecycle subcommands: %v", want)
+	}
+}
+
+func TestLoadLifecycleRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lifecycle.yaml")
+	data := `rules:
+  - id: nightly
+    prefix: nightly/
+    cold_days: 30
+    expire_days: 90
+  - id: uploads
+    prefix: ""
+    abort_multipart_days: 3
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadLifecycleRules(path)
+	if err != nil {
+		t.Fatalf("loadLifecycleRules failed: %v", err)
+	}
+	if len(rules) != 2 || rules[0].ColdDays != 30 || rules[1].AbortMultipartDays != 3 {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	if err := os.WriteFile(path, []byte("rules:\n  - id: bad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadLifecycleRules(path); err == nil {
+		t.Error("expected a rule without actions to be rejected")
+	}
+}
+
+func TestLifecycleRuleFromFlags(t *testing.T) {
+	cmd := NewOBSLifecycleSetCommand()
+	cmd.ParseFlags([]string{"--prefix", "builds/nightly/", "--expire-days", "30"})
+	rule := lifecycleRuleFromFlags(cmd)
+	if rule.ID != "builds-nightly" || rule.Prefix != "builds/nightly/" || rule.ExpireDays != 30 {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+	if got := lifecycleRuleID(""); got != "bucket" {
+		t.Errorf("expected bucket-wide id, got %q", got)
+	}
+}
+
+// runAgainst runs the root command for the environment profile against a
+// server accepting every request, and returns stdout
+func runAgainst(t *testing.T, args ...string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { config.UseEnv(false) })
+	t.Setenv(config.EnvProfile, "")
+	t.Setenv(config.EnvEndpoint, server.URL)
+	t.Setenv(config.EnvBucket, "bucket")
+	t.Setenv(config.EnvAccessKey, "ak")
+	t.Setenv(config.EnvSecretKey, "sk")
+	t.Setenv("OBSPUT_ENV_AK", "")
+	t.Setenv("OBSPUT_ENV_SK", "")
+
+	var stdout bytes.Buffer
+	root := NewRootCommand()
+	root.SetOut(&stdout)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs(append([]string{"--profile-from-env"}, args...))
+	if err := root.Execute(); err != nil {
+		t.Fatalf("%v failed: %v", args, err)
+	}
+	return stdout.String()
+}
+
+func TestLifecycleDeleteJSON(t *testing.T) {
+	if got := strings.TrimSpace(runAgainst(t, "obs", "lifecycle", "delete", "env", "-o", "json")); got != "[]" {
+		t.Errorf("expected no rules on stdout, got %q", got)
+	}
+}
@@ -2,9 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	obsclient "obsput/pkg/obs"
 
 	"obsput/pkg/config"
+	obsclient "obsput/pkg/obs"
 	"obsput/pkg/output"
 	"obsput/pkg/styled"
 
@@ -15,102 +15,205 @@ func NewListCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List uploaded versions",
+		Long: `List uploaded versions.
+
+Examples:
+  # Ten newest uploads from the last week
+  obsput list --since 7d --sort date --reverse --limit 10
+
+  # Linux builds of one commit under the releases prefix
+  obsput list --prefix releases --commit abc123 --name "*linux*"
+
+  # Every stored copy of one object, in a bucket with versioning enabled
+  obsput list --history v1.0.0-abc123-20260214-153045-1/app-linux-amd64 --profile prod`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			outputFormat, _ := cmd.Flags().GetString("output")
 			profile, _ := cmd.Flags().GetString("profile")
+			showFiles, _ := cmd.Flags().GetBool("files")
+			history, _ := cmd.Flags().GetString("history")
 
-			// Load config
-			cfg, err := config.LoadOrInit()
+			opts, err := listOptionsFromFlags(cmd)
 			if err != nil {
-				return fmt.Errorf("load config failed: %v\nRun: obsput obs add --name prod --endpoint \"xxx\" --bucket \"xxx\" --ak \"xxx\" --sk \"xxx\"", err)
+				return err
 			}
 
-			if len(cfg.Configs) == 0 {
-				return fmt.Errorf("No OBS configurations configured\n\nConfig file: %s\n\nAdd OBS:\n  obsput obs add --name prod --endpoint \"obs.xxx.com\" --bucket \"bucket\" --ak \"xxx\" --sk \"xxx\"", getConfigPath())
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
 			}
 
-			// Determine which configs to list
-			var configsToUse map[string]*config.OBS
-			if profile != "" {
-				obsCfg := cfg.GetOBS(profile)
-				if obsCfg == nil {
-					return fmt.Errorf("profile '%s' not found in config\n\nRun: obsput obs list", profile)
-				}
-				configsToUse = map[string]*config.OBS{
-					profile: obsCfg,
-				}
-			} else {
-				configsToUse = cfg.Configs
+			// Messages go to stderr, versions to stdout
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
 			}
 
-			// Create styled output
-			out := styled.NewOutput()
-			formatter := output.NewFormatter()
+			if history != "" {
+				return listHistory(out, formatter, configsToUse, history)
+			}
 
 			out.Section("Versions")
 			out.Divider()
 
-			totalVersions := 0
-			for name, obsCfg := range configsToUse {
-				// Print profile header
-				out.Println(styled.Info, " "+name)
-				cmd.Println()
-
-				client := obsclient.NewClient(obsCfg.Endpoint, obsCfg.Bucket, obsCfg.AK, obsCfg.SK)
-				versions, err := client.ListVersions("")
+			items := make([]output.VersionGroupItem, 0)
+			for _, name := range sortedProfileNames(configsToUse) {
+				obsCfg := configsToUse[name]
+				client, err := newClient(obsCfg)
 				if err != nil {
-					out.ErrorMsg(fmt.Sprintf("Failed to list versions: %v", err))
-					cmd.Println()
+					out.ErrorMsg(err.Error())
 					continue
 				}
-
-				if len(versions) == 0 {
-					out.Println(styled.Muted, "   No versions found")
-					cmd.Println()
+				groups, err := client.ListVersionGroups(opts)
+				if err != nil {
+					out.ErrorMsg(fmt.Sprintf("%s: failed to list versions: %v", name, err))
 					continue
 				}
-
-				items := make([]output.VersionItem, 0, len(versions))
-				for _, v := range versions {
-					items = append(items, output.VersionItem{
-						Version: v.Version,
-						Size:    v.Size,
-						Date:    v.Date,
-						Commit:  v.Commit,
-						URL:     v.URL,
-					})
-					totalVersions++
+				if len(groups) == 0 {
+					out.Println(styled.Muted, fmt.Sprintf("  %s: no versions found", name))
+					continue
 				}
-
-				if outputFormat == "json" {
-					formatter.PrintJSON(items)
-				} else {
-					// Print versions as cards
-					for _, item := range items {
-						out.Spacer()
-						content := map[string]string{
-							"Version": item.Version,
-							"Size":    item.Size,
-							"Date":    item.Date,
-							"Commit":  item.Commit,
-						}
-						out.PrintBox("Version Info", content)
-					}
-					out.Spacer()
+				for _, g := range groups {
+					item := versionGroupItem(g, formatter, showFiles)
+					item.Profile = name
+					items = append(items, item)
 				}
-				cmd.Println()
 			}
 
-			if totalVersions > 0 {
-				out.Section(fmt.Sprintf("Total: %d versions", totalVersions))
+			if len(items) > 0 {
+				out.Section(fmt.Sprintf("Total: %d versions", len(items)))
 			}
 
-			return nil
+			// Tables and CSV get one row per object when versions are expanded
+			if showFiles && (formatter.Format() == output.FormatTable || formatter.Format() == output.FormatCSV) {
+				files := make([]output.VersionItem, 0)
+				for _, item := range items {
+					for _, f := range item.Files {
+						f.Profile = item.Profile
+						files = append(files, f)
+					}
+				}
+				return formatter.Render(files)
+			}
+			return formatter.Render(items)
 		},
 	}
-	cmd.Flags().StringP("output", "o", "table", "Output format (table/json)")
 	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
+	cmd.Flags().String("prefix", "", "Only versions uploaded with this prefix")
+	cmd.Flags().String("since", "", "Only objects modified at or after this time (YYYY-MM-DD or Nd, Nh)")
+	cmd.Flags().String("until", "", "Only objects modified before this time (YYYY-MM-DD or Nd, Nh)")
+	cmd.Flags().String("commit", "", "Only versions built from this commit (prefix match)")
+	cmd.Flags().String("branch", "", "Only versions from this branch (requires {branch} in the key template)")
+	cmd.Flags().String("name", "", "Only files whose name matches this glob (e.g. \"*linux*\")")
+	cmd.Flags().Int("limit", 0, "Show at most N versions per profile (0: no limit)")
+	cmd.Flags().String("sort", "", "Sort versions by version, newest date or total size (default: key order)")
+	cmd.Flags().Bool("files", false, "Expand each version into its individual objects")
+	cmd.Flags().Bool("reverse", false, "Reverse the sort order")
+	cmd.Flags().String("history", "", "List every stored copy of this object key (needs bucket versioning)")
 	return cmd
 }
 
+// listHistory lists the stored copies of one object key, newest first
+func listHistory(out *styled.Output, formatter *output.Formatter, configsToUse map[string]*config.OBS, key string) error {
+	out.Section("History of " + key)
+	out.Divider()
+
+	items := make([]output.HistoryItem, 0)
+	for _, name := range sortedProfileNames(configsToUse) {
+		client, err := newClient(configsToUse[name])
+		if err != nil {
+			out.ErrorMsg(err.Error())
+			continue
+		}
+		// Without versioning there is only the current copy
+		if status, err := client.GetVersioning(); err == nil && status != obsclient.VersioningEnabled {
+			out.WarningMsg(fmt.Sprintf("%s: versioning is %s, older copies may not be kept\n  Run: obsput obs versioning enable %s", name, status, name))
+		}
+		versions, err := client.ListObjectVersions(key)
+		if err != nil {
+			out.ErrorMsg(fmt.Sprintf("%s: failed to list object versions: %v", name, err))
+			continue
+		}
+		if len(versions) == 0 {
+			out.Println(styled.Muted, fmt.Sprintf("  %s: no versions found", name))
+			continue
+		}
+		for _, v := range versions {
+			items = append(items, output.HistoryItem{
+				Profile:      name,
+				Key:          v.Key,
+				VersionID:    v.VersionID,
+				Latest:       v.IsLatest,
+				DeleteMarker: v.IsDeleteMarker,
+				Size:         formatter.FormatSize(v.Size),
+				SizeBytes:    v.Size,
+				Date:         v.LastModified.Format("2006-01-02 15:04:05"),
+			})
+		}
+	}
+	return formatter.Render(items)
+}
+
+// versionGroupItem converts a version group for output; files are only
+// included when expanded with --files
+func versionGroupItem(g obsclient.VersionGroup, formatter *output.Formatter, showFiles bool) output.VersionGroupItem {
+	item := output.VersionGroupItem{
+		Version:   g.Version,
+		Commit:    g.Commit,
+		FileCount: len(g.Files),
+		Size:      formatter.FormatSize(g.TotalSize),
+		SizeBytes: g.TotalSize,
+		Date:      g.LastModified.Format("2006-01-02 15:04:05"),
+		Filenames: g.Filenames(),
+	}
+	if showFiles {
+		for _, f := range g.Files {
+			item.Files = append(item.Files, output.VersionItem{
+				Version:  f.Version,
+				Filename: f.Values["filename"],
+				Size:     f.Size,
+				Date:     f.Date,
+				Commit:   f.Commit,
+				URL:      f.URL,
+			})
+		}
+	}
+	return item
+}
+
+// listOptionsFromFlags builds list filters from the command flags
+func listOptionsFromFlags(cmd *cobra.Command) (obsclient.ListOptions, error) {
+	var opts obsclient.ListOptions
+	opts.Prefix, _ = cmd.Flags().GetString("prefix")
+	opts.Commit, _ = cmd.Flags().GetString("commit")
+	opts.Branch, _ = cmd.Flags().GetString("branch")
+	opts.Name, _ = cmd.Flags().GetString("name")
+	opts.Limit, _ = cmd.Flags().GetInt("limit")
+	opts.Sort, _ = cmd.Flags().GetString("sort")
+	opts.Reverse, _ = cmd.Flags().GetBool("reverse")
+
+	if err := obsclient.ValidateSort(opts.Sort); err != nil {
+		return opts, err
+	}
+	if opts.Limit < 0 {
+		return opts, fmt.Errorf("--limit must not be negative")
+	}
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		t, err := parseBeforeDate(since)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --since format: %v\nUse format: YYYY-MM-DD or Nd (e.g., 7d, 24h)", err)
+		}
+		opts.Since = t
+	}
+	if until, _ := cmd.Flags().GetString("until"); until != "" {
+		t, err := parseBeforeDate(until)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --until format: %v\nUse format: YYYY-MM-DD or Nd (e.g., 7d, 24h)", err)
+		}
+		opts.Until = t
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Since.Before(opts.Until) {
+		return opts, fmt.Errorf("--since must be earlier than --until")
+	}
+	return opts, nil
+}
+
 func init() {}
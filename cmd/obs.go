@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"obsput/pkg/config"
+	"obsput/pkg/layout"
 	"obsput/pkg/obs"
+	"obsput/pkg/output"
 	"obsput/pkg/styled"
 
 	"github.com/spf13/cobra"
@@ -22,6 +26,11 @@ func NewOBSCommand() *cobra.Command {
 	cmd.AddCommand(NewOBSRemoveCommand())
 	cmd.AddCommand(NewOBSInitCommand())
 	cmd.AddCommand(NewOBSMakeBucketCommand())
+	cmd.AddCommand(NewOBSRemoveBucketCommand())
+	cmd.AddCommand(NewOBSLifecycleCommand())
+	cmd.AddCommand(NewOBSVersioningCommand())
+	cmd.AddCommand(NewOBSLockCommand())
+	cmd.AddCommand(NewOBSUnlockCommand())
 	return cmd
 }
 
@@ -35,13 +44,48 @@ func NewOBSAddCommand() *cobra.Command {
 			bucket, _ := cmd.Flags().GetString("bucket")
 			ak, _ := cmd.Flags().GetString("ak")
 			sk, _ := cmd.Flags().GetString("sk")
+			akEnv, _ := cmd.Flags().GetString("ak-env")
+			skEnv, _ := cmd.Flags().GetString("sk-env")
+			credentialProcess, _ := cmd.Flags().GetString("credential-process")
+			securityToken, _ := cmd.Flags().GetString("security-token")
+			securityTokenEnv, _ := cmd.Flags().GetString("security-token-env")
+			keyTemplate, _ := cmd.Flags().GetString("key-template")
+			project, _ := cmd.Flags().GetString("project")
+			tlsOpts := &obs.TLSOptions{}
+			tlsOpts.CABundle, _ = cmd.Flags().GetString("ca-bundle")
+			tlsOpts.ClientCert, _ = cmd.Flags().GetString("client-cert")
+			tlsOpts.ClientKey, _ = cmd.Flags().GetString("client-key")
+			tlsOpts.InsecureSkipVerify, _ = cmd.Flags().GetBool("insecure-skip-verify")
+			httpOpts := &obs.HTTPOptions{}
+			httpOpts.Proxy, _ = cmd.Flags().GetString("proxy")
+			httpOpts.NoProxy, _ = cmd.Flags().GetString("no-proxy")
+			httpOpts.ConnectTimeout, _ = cmd.Flags().GetDuration("connect-timeout")
+			httpOpts.SocketTimeout, _ = cmd.Flags().GetDuration("socket-timeout")
+			httpOpts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+			httpOpts.MaxIdleConns, _ = cmd.Flags().GetInt("max-idle-conns")
+			httpOpts.TCPCheckTimeout, _ = cmd.Flags().GetDuration("tcp-check-timeout")
 
 			cfg, err := config.LoadOrInit()
 			if err != nil {
-				return fmt.Errorf("load config failed: %v", err)
+				return configError(fmt.Errorf("load config failed: %v", err))
 			}
 
 			cfg.AddOBS(name, endpoint, bucket, ak, sk)
+			obsCfg := cfg.GetOBS(name)
+			obsCfg.AKEnv, obsCfg.SKEnv = akEnv, skEnv
+			obsCfg.CredentialProcess = credentialProcess
+			obsCfg.SecurityToken, obsCfg.SecurityTokenEnv = securityToken, securityTokenEnv
+			obsCfg.KeyTemplate = keyTemplate
+			obsCfg.Project = project
+			if *tlsOpts != (obs.TLSOptions{}) {
+				obsCfg.TLS = tlsOpts
+			}
+			if *httpOpts != (obs.HTTPOptions{}) {
+				obsCfg.HTTP = httpOpts
+			}
+			if err := obsCfg.Validate(); err != nil {
+				return configError(err)
+			}
 
 			if err := cfg.Save(getConfigPath()); err != nil {
 				return fmt.Errorf("save config failed: %v", err)
@@ -56,11 +100,32 @@ func NewOBSAddCommand() *cobra.Command {
 	cmd.Flags().String("bucket", "", "OBS bucket")
 	cmd.Flags().String("ak", "", "Access Key")
 	cmd.Flags().String("sk", "", "Secret Key")
+	cmd.Flags().String("ak-env", "", "Environment variable holding the Access Key, instead of --ak")
+	cmd.Flags().String("sk-env", "", "Environment variable holding the Secret Key, instead of --sk")
+	cmd.Flags().String("credential-process", "", "Command printing short-lived credentials as JSON, instead of keys")
+	cmd.Flags().String("security-token", "", "Security token of temporary (STS) credentials")
+	cmd.Flags().String("security-token-env", "", "Environment variable holding the security token, with --ak-env")
+	cmd.Flags().String("key-template", "", "Object key layout (default: "+layout.Default+")")
+	cmd.Flags().String("project", "", "Value for {project} in the key template")
+	cmd.Flags().String("ca-bundle", "", "PEM file of CA certificates to trust for the endpoint")
+	cmd.Flags().String("client-cert", "", "PEM client certificate for mutual TLS")
+	cmd.Flags().String("client-key", "", "PEM key of the client certificate")
+	cmd.Flags().Bool("insecure-skip-verify", false, "Do not verify the endpoint certificate (testing only)")
+	cmd.Flags().String("proxy", "", "Proxy URL, or \"direct\" for none (default HTTPS_PROXY/HTTP_PROXY)")
+	cmd.Flags().String("no-proxy", "", "Hosts reached without the proxy (default NO_PROXY)")
+	cmd.Flags().Duration("connect-timeout", 0, "Timeout for establishing a connection (default 60s)")
+	cmd.Flags().Duration("socket-timeout", 0, "Timeout for each read and write (default 60s)")
+	cmd.Flags().Duration("timeout", 0, "Timeout for a whole request, e.g. 30m (default none)")
+	cmd.Flags().Int("max-idle-conns", 0, "Idle connections kept for reuse (default 1000)")
+	cmd.Flags().Duration("tcp-check-timeout", 0, "Timeout of the connection test before each operation (default 3s)")
 	cmd.MarkFlagRequired("name")
 	cmd.MarkFlagRequired("endpoint")
 	cmd.MarkFlagRequired("bucket")
-	cmd.MarkFlagRequired("ak")
-	cmd.MarkFlagRequired("sk")
+	cmd.MarkFlagsMutuallyExclusive("ak", "ak-env")
+	cmd.MarkFlagsMutuallyExclusive("sk", "sk-env")
+	cmd.MarkFlagsMutuallyExclusive("ak", "credential-process")
+	cmd.MarkFlagsMutuallyExclusive("ak-env", "credential-process")
+	cmd.MarkFlagsMutuallyExclusive("security-token", "security-token-env")
 	return cmd
 }
 
@@ -69,31 +134,28 @@ func NewOBSListCommand() *cobra.Command {
 		Use:   "list",
 		Short: "List OBS configurations",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.LoadOrInit()
+			cfg, err := loadConfig()
 			if err != nil {
-				return fmt.Errorf("load config failed: %v\nRun: obsput obs add --name prod --endpoint \"xxx\" --bucket \"xxx\" --ak \"xxx\" --sk \"xxx\"", err)
+				return err
 			}
 
-			// Create styled output
-			out := styled.NewOutput()
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
 
 			out.Section("OBS Configurations")
 			out.Divider()
 
-			for _, obs := range cfg.ListOBS() {
-				content := map[string]string{
-					"Name":     obs.Name,
-					"Endpoint": obs.Endpoint,
-					"Bucket":   obs.Bucket,
-					"Status":   "✓ Active",
-				}
-				out.PrintBox("OBS", content)
-				out.Spacer()
+			items := make([]output.ProfileItem, 0, len(cfg.Configs))
+			for _, name := range sortedProfileNames(cfg.Configs) {
+				items = append(items, profileItem(cfg.Configs[name]))
 			}
 
-			out.Section(fmt.Sprintf("Total: %d configurations", len(cfg.ListOBS())))
+			out.Section(fmt.Sprintf("Total: %d configurations", len(items)))
+			out.Spacer()
 
-			return nil
+			return formatter.Render(items)
 		},
 	}
 	return cmd
@@ -106,28 +168,94 @@ func NewOBSGetCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
+			offline, _ := cmd.Flags().GetBool("offline")
 
 			cfg, err := config.LoadOrInit()
 			if err != nil {
-				return fmt.Errorf("load config failed: %v", err)
+				return configError(fmt.Errorf("load config failed: %v", err))
 			}
 
 			obs := cfg.GetOBS(name)
 			if obs == nil {
-				return fmt.Errorf("OBS config not found: %s", name)
+				return configError(fmt.Errorf("OBS config not found: %s", name))
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			// The bucket's lifecycle rules are the only remote part; the
+			// rest of the profile is shown even when the bucket is unreachable
+			item := profileItem(obs)
+			var lifecycleErr error
+			if !offline {
+				item.Lifecycle, lifecycleErr = bucketLifecycle(obs)
+			}
+			if formatter.Format() != output.FormatTable {
+				if lifecycleErr != nil {
+					out.WarningMsg(fmt.Sprintf("Lifecycle unavailable: %v", lifecycleErr))
+				}
+				return formatter.Render(item)
 			}
 
 			cmd.Printf("Name: %s\n", obs.Name)
 			cmd.Printf("Endpoint: %s\n", obs.Endpoint)
 			cmd.Printf("Bucket: %s\n", obs.Bucket)
-			cmd.Printf("AK: %s\n", maskAK(obs.AK))
-			cmd.Printf("SK: %s\n", maskSK(obs.SK))
+			cmd.Printf("AK: %s\n", describeCredential(item.AK, item.AKSource))
+			cmd.Printf("SK: %s\n", describeCredential(item.SK, item.SKSource))
+			if item.TokenSource != "" {
+				cmd.Printf("Security Token: %s\n", describeCredential(item.Token, item.TokenSource))
+			}
+			cmd.Printf("Key Template: %s\n", obs.GetKeyTemplate())
+			if obs.Project != "" {
+				cmd.Printf("Project: %s\n", obs.Project)
+			}
+			if obs.Retention != nil {
+				cmd.Printf("Retention: %s\n", obs.Retention)
+			}
+			if item.TLS != "" {
+				cmd.Printf("TLS: %s\n", item.TLS)
+			}
+			if item.HTTP != "" {
+				cmd.Printf("HTTP: %s\n", item.HTTP)
+			}
+			switch {
+			case offline:
+			case lifecycleErr != nil:
+				cmd.Printf("Lifecycle: unavailable (%v)\n", lifecycleErr)
+			case len(item.Lifecycle) == 0:
+				cmd.Printf("Lifecycle: none\n")
+			default:
+				cmd.Printf("Lifecycle:\n")
+				for _, rule := range item.Lifecycle {
+					cmd.Printf("  %s\n", rule)
+				}
+			}
 			return nil
 		},
 	}
+	cmd.Flags().Bool("offline", false, "Only show the local config, without the bucket's lifecycle rules")
 	return cmd
 }
 
+// bucketLifecycle describes the lifecycle rules of a profile's bucket
+func bucketLifecycle(obsCfg *config.OBS) ([]string, error) {
+	client, err := newClient(obsCfg)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := client.GetLifecycle()
+	if err != nil {
+		return nil, err
+	}
+	descriptions := make([]string, 0, len(rules))
+	for _, r := range rules {
+		descriptions = append(descriptions, r.String())
+	}
+	return descriptions, nil
+}
+
 func NewOBSRemoveCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "remove <name>",
@@ -138,11 +266,11 @@ func NewOBSRemoveCommand() *cobra.Command {
 
 			cfg, err := config.LoadOrInit()
 			if err != nil {
-				return fmt.Errorf("load config failed: %v", err)
+				return configError(fmt.Errorf("load config failed: %v", err))
 			}
 
 			if !cfg.OBSExists(name) {
-				return fmt.Errorf("OBS config not found: %s", name)
+				return configError(fmt.Errorf("OBS config not found: %s", name))
 			}
 
 			cfg.RemoveOBS(name)
@@ -181,41 +309,51 @@ func NewOBSInitCommand() *cobra.Command {
 
 func NewOBSMakeBucketCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "mb [name]",
+		Use:   "mb [profile]",
 		Short: "Create bucket for OBS",
 		Long: `Create bucket for specified OBS profile.
 If no profile is specified, creates bucket for all configured OBS.
-Returns error if bucket already exists.`,
+Returns error if bucket already exists.
+
+The location defaults to the region of a Huawei Cloud endpoint such as
+obs.cn-north-4.myhuaweicloud.com.
+
+Examples:
+  obsput obs mb prod --acl public-read --storage-class WARM --versioning
+  obsput obs mb --profile prod --location cn-north-4`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.LoadOrInit()
-			if err != nil {
-				return fmt.Errorf("load config failed: %v", err)
+			profile, _ := cmd.Flags().GetString("profile")
+			location, _ := cmd.Flags().GetString("location")
+			acl, _ := cmd.Flags().GetString("acl")
+			storageClass, _ := cmd.Flags().GetString("storage-class")
+			versioning, _ := cmd.Flags().GetBool("versioning")
+
+			if len(args) > 0 && args[0] != "" {
+				if profile != "" && profile != args[0] {
+					return configError(fmt.Errorf("profile given twice: %s and --profile %s", args[0], profile))
+				}
+				profile = args[0]
 			}
 
-			if len(cfg.Configs) == 0 {
-				return fmt.Errorf("no OBS configurations configured\n\nRun: obsput obs add --name prod --endpoint \"obs.xxx.com\" --bucket \"bucket\" --ak \"xxx\" --sk \"xxx\"")
+			opts := obs.BucketOptions{
+				ACL:          strings.ToLower(acl),
+				StorageClass: strings.ToUpper(storageClass),
+				Versioning:   versioning,
+			}
+			if err := opts.Validate(); err != nil {
+				return configError(err)
 			}
 
-			// Determine which configs to use
-			var configsToUse map[string]*config.OBS
-			if len(args) > 0 && args[0] != "" {
-				// Use specific profile
-				name := args[0]
-				obsCfg := cfg.GetOBS(name)
-				if obsCfg == nil {
-					return fmt.Errorf("OBS config not found: %s\n\nRun: obsput obs list", name)
-				}
-				configsToUse = map[string]*config.OBS{
-					name: obsCfg,
-				}
-			} else {
-				// All configs
-				configsToUse = cfg.Configs
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
 			}
 
-			// Create styled output
-			out := styled.NewOutput()
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
 
 			out.Divider()
 			out.Section("Create Buckets")
@@ -225,15 +363,25 @@ Returns error if bucket already exists.`,
 			var mu sync.Mutex
 			var wg sync.WaitGroup
 			results := make([]*obs.BucketResult, 0, len(configsToUse))
+			locations := make(map[string]string, len(configsToUse))
 
 			for name, obsCfg := range configsToUse {
+				// Each profile may be in another region
+				profileOpts := opts
+				profileOpts.Location = location
+				if profileOpts.Location == "" {
+					profileOpts.Location = obs.RegionFromEndpoint(obsCfg.Endpoint)
+				}
+				locations[name] = profileOpts.Location
+
 				wg.Add(1)
-				go func(name string, obsCfg *config.OBS) {
+				go func(name string, obsCfg *config.OBS, opts obs.BucketOptions) {
 					defer wg.Done()
 
-					client := obs.NewClient(obsCfg.Endpoint, obsCfg.Bucket, obsCfg.AK, obsCfg.SK)
-
-					err := client.CreateBucket()
+					client, err := newClient(obsCfg)
+					if err == nil {
+						err = client.CreateBucketWithOptions(opts)
+					}
 					result := &obs.BucketResult{
 						OBSName: name,
 						Bucket:  obsCfg.Bucket,
@@ -241,39 +389,227 @@ Returns error if bucket already exists.`,
 					}
 					if err != nil {
 						result.Error = err.Error()
+						result.Err = err
 					}
 
 					mu.Lock()
 					results = append(results, result)
 					mu.Unlock()
-				}(name, obsCfg)
+				}(name, obsCfg, profileOpts)
 			}
 
 			wg.Wait()
 
 			// Print results
+			sort.Slice(results, func(i, j int) bool { return results[i].OBSName < results[j].OBSName })
 			successCount := 0
 			failCount := 0
+			items := make([]output.BucketItem, 0, len(results))
+			tally := newOutcome(requirement{all: true}, len(results))
 			for _, r := range results {
+				item := output.BucketItem{
+					Profile:      r.OBSName,
+					Bucket:       r.Bucket,
+					Location:     locations[r.OBSName],
+					ACL:          opts.ACL,
+					StorageClass: opts.StorageClass,
+					Versioning:   opts.Versioning,
+					Status:       "created",
+				}
 				if r.Success {
 					out.SuccessMsg(fmt.Sprintf("%s: %s", r.OBSName, r.Bucket))
+					tally.success()
 					successCount++
 				} else {
 					out.ErrorMsg(fmt.Sprintf("%s: %s (%s)", r.OBSName, r.Bucket, r.Error))
+					item.Status = "failed"
+					item.Error = r.Error
+					tally.failure(r.Err)
 					failCount++
 				}
+				items = append(items, item)
 			}
 
 			out.Section("Summary")
 			out.Summary(successCount, failCount)
+			out.Spacer()
 
-			return nil
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			return tally.err("create bucket")
 		},
 	}
 	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
+	cmd.Flags().String("location", "", "Region to create the bucket in (default: from a Huawei Cloud endpoint)")
+	cmd.Flags().String("acl", "", "Default bucket ACL: "+strings.Join(obs.BucketACLs, ", "))
+	cmd.Flags().String("storage-class", "", "Default storage class: "+strings.Join(obs.StorageClasses, ", "))
+	cmd.Flags().Bool("versioning", false, "Enable versioning on the new bucket")
 	return cmd
 }
 
+func NewOBSRemoveBucketCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rb <profile>",
+		Short: "Remove a profile's bucket",
+		Long: `Remove the bucket of a profile. The bucket must be empty unless --force is
+given, which first deletes every object, including all stored versions, and
+aborts incomplete multipart uploads. The profile itself is kept; remove it
+with "obsput obs remove".
+
+Examples:
+  obsput obs rb staging
+  obsput obs rb staging --force --yes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			force, _ := cmd.Flags().GetBool("force")
+			yes, _ := cmd.Flags().GetBool("yes")
+
+			configs, err := selectProfiles(name)
+			if err != nil {
+				return err
+			}
+			obsCfg := configs[name]
+			if force && obsCfg.Protect != nil {
+				return configError(fmt.Errorf("profile %s has a protection list (%s); remove it from the config before emptying the bucket", name, obsCfg.Protect))
+			}
+
+			client, err := newClient(obsCfg)
+			if err != nil {
+				return err
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			out.Divider()
+			out.Section("Remove Bucket")
+			out.KeyValue("Profile", name)
+			out.KeyValue("Bucket", obsCfg.Bucket)
+			out.Divider()
+
+			item := output.RemoveBucketItem{Profile: name, Bucket: obsCfg.Bucket}
+			question := fmt.Sprintf("Remove bucket %s of profile %s?", obsCfg.Bucket, name)
+
+			var contents *obs.BucketContents
+			if force {
+				contents, err = client.ListBucketContents()
+				if err != nil {
+					return withExitCode(kindExitCode(obs.Classify(err)), fmt.Errorf("list bucket %s failed: %w", obsCfg.Bucket, err))
+				}
+				if !contents.IsEmpty() {
+					out.Printf(styled.Warning, "  delete  %d object version(s), %s\n", len(contents.Versions), formatter.FormatSize(contents.TotalSize()))
+					out.Printf(styled.Warning, "  abort   %d incomplete multipart upload(s)\n", len(contents.Uploads))
+					question = fmt.Sprintf("Permanently delete %d object version(s), %s, abort %d upload(s) and remove bucket %s of profile %s?",
+						len(contents.Versions), formatter.FormatSize(contents.TotalSize()), len(contents.Uploads), obsCfg.Bucket, name)
+				}
+			}
+
+			if err := confirm(cmd, "remove the bucket", question, yes); err != nil {
+				return err
+			}
+
+			if contents != nil && !contents.IsEmpty() {
+				result := client.EmptyBucket(contents)
+				item.Objects = result.Deleted
+				item.Uploads = result.Aborted
+				if len(result.Failed) > 0 {
+					item.Status = "failed"
+					item.Error = fmt.Sprintf("%d object(s) or upload(s) not removed: %s", len(result.Failed), result.Failed[0].Error)
+					out.ErrorMsg(item.Error)
+					for _, f := range result.Failed {
+						out.Printf(styled.Muted, "  remaining: %s\n", f.Key)
+					}
+					if err := formatter.Render([]output.RemoveBucketItem{item}); err != nil {
+						return err
+					}
+					code := ExitFailure
+					if result.Err != nil {
+						code = kindExitCode(obs.Classify(result.Err))
+					}
+					return withExitCode(code, fmt.Errorf("empty bucket %s failed: %s", obsCfg.Bucket, item.Error))
+				}
+				out.SuccessMsg(fmt.Sprintf("Deleted %d object version(s), aborted %d upload(s)", result.Deleted, result.Aborted))
+			}
+
+			if err := client.DeleteBucket(); err != nil {
+				item.Status = "failed"
+				item.Error = err.Error()
+				out.ErrorMsg(err.Error())
+				if renderErr := formatter.Render([]output.RemoveBucketItem{item}); renderErr != nil {
+					return renderErr
+				}
+				if !force && obs.IsBucketNotEmpty(err) {
+					return fmt.Errorf("bucket %s is not empty\n\nRun: obsput obs rb %s --force", obsCfg.Bucket, name)
+				}
+				return withExitCode(kindExitCode(obs.Classify(err)), fmt.Errorf("remove bucket %s failed: %w", obsCfg.Bucket, err))
+			}
+
+			item.Status = "removed"
+			out.SuccessMsg(fmt.Sprintf("Removed bucket %s", obsCfg.Bucket))
+			out.Spacer()
+			return formatter.Render([]output.RemoveBucketItem{item})
+		},
+	}
+	cmd.Flags().Bool("force", false, "Delete all objects, versions and incomplete uploads first")
+	cmd.Flags().BoolP("yes", "y", false, "Remove without asking for confirmation (required when not interactive)")
+	return cmd
+}
+
+// profileItem describes a profile for output with masked credentials, as
+// resolved from the environment and the config file
+func profileItem(obsCfg *config.OBS) output.ProfileItem {
+	ak, akSource, _ := obsCfg.AccessKey()
+	sk, skSource, _ := obsCfg.SecretKey()
+	token, tokenSource, _ := obsCfg.Token()
+	item := output.ProfileItem{
+		Name:        obsCfg.Name,
+		Endpoint:    obsCfg.Endpoint,
+		Bucket:      obsCfg.Bucket,
+		AK:          maskAK(ak),
+		SK:          maskSK(sk),
+		AKSource:    akSource,
+		SKSource:    skSource,
+		Token:       maskToken(token),
+		TokenSource: tokenSource,
+		KeyTemplate: obsCfg.GetKeyTemplate(),
+		Project:     obsCfg.Project,
+		TLS:         obsCfg.TLS.String(),
+		HTTP:        obsCfg.HTTP.String(),
+	}
+	if obsCfg.Retention != nil {
+		item.Retention = obsCfg.Retention.String()
+	}
+	return item
+}
+
+// describeCredential shows a masked key with where it comes from
+func describeCredential(masked, source string) string {
+	switch source {
+	case "":
+		return "not set"
+	case "config":
+		return masked
+	case "credential_process":
+		return "from credential_process"
+	}
+	return fmt.Sprintf("%s (%s)", masked, source)
+}
+
+// maskToken hides a security token but its last four characters
+func maskToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 16 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
+
 func maskAK(ak string) string {
 	if len(ak) <= 4 {
 		return "****"
@@ -35,3 +35,37 @@ func TestNewOBSInitCommand(t *testing.T) {
 		t.Errorf("expected use 'init', got '%s'", cmd.Use)
 	}
 }
+
+func TestOBSMakeBucketRejectsBadFlags(t *testing.T) {
+	tests := [][]string{
+		{"prod", "--profile", "staging"},
+		{"prod", "--acl", "everyone"},
+		{"prod", "--storage-class", "hot"},
+	}
+	for _, args := range tests {
+		cmd := NewOBSMakeBucketCommand()
+		cmd.SetOut(bytes.NewBufferString(""))
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		if err == nil {
+			t.Errorf("mb %v: expected an error", args)
+			continue
+		}
+		if code := exitCode(err); code != ExitConfig {
+			t.Errorf("mb %v: exit code %d, want %d (%v)", args, code, ExitConfig, err)
+		}
+	}
+}
+
+func TestOBSRemoveBucketCommand(t *testing.T) {
+	cmd := NewOBSRemoveBucketCommand()
+	for _, name := range []string{"force", "yes"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected --%s flag", name)
+		}
+	}
+	if err := cmd.Args(cmd, nil); err == nil {
+		t.Error("rb should require a profile")
+	}
+}
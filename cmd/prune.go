@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"obsput/pkg/config"
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/retention"
+	"obsput/pkg/styled"
+
+	"github.com/spf13/cobra"
+)
+
+func NewPruneCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete versions not kept by the retention policy",
+		Long: `Delete versions that no retention rule of the profile keeps.
+
+Rules are set per profile in the config file; a version is kept when any
+rule keeps it:
+
+  retention:
+    keep_last: 10      # newest 10 versions per location (prefix/project/branch)
+    keep_days: 30      # anything uploaded in the last 30 days
+    keep_tagged: true  # versions built from a git tag
+    keep_monthly: true # newest version of each month per location
+
+Versions matched by the profile's protect list are always kept.
+
+The plan is always printed first; use --dry-run to stop there, or --yes to
+delete without being asked.
+
+Examples:
+  # Show what would be deleted
+  obsput prune --dry-run
+
+  # Prune one profile from a script
+  obsput prune --profile prod --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, _ := cmd.Flags().GetString("profile")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			requireFlag, _ := cmd.Flags().GetString("require")
+			failFast, _ := cmd.Flags().GetBool("fail-fast")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			yes, _ := cmd.Flags().GetBool("yes")
+
+			require, err := parseRequire(requireFlag)
+			if err != nil {
+				return err
+			}
+
+			profiles, err := selectProfiles(profile)
+			if err != nil {
+				return err
+			}
+
+			// Only profiles with a retention policy are pruned
+			configsToUse := make(map[string]*config.OBS)
+			for name, obsCfg := range profiles {
+				if obsCfg.Retention != nil {
+					configsToUse[name] = obsCfg
+				}
+			}
+			if profile != "" && len(configsToUse) == 0 {
+				return configError(fmt.Errorf("profile '%s' has no retention policy\n\nConfig file: %s", profile, getConfigPath()))
+			}
+			if len(configsToUse) == 0 {
+				return configError(fmt.Errorf("no profile has a retention policy\n\nAdd a retention section to a profile in %s, e.g.:\n  retention:\n    keep_last: 10", getConfigPath()))
+			}
+			if err := require.check(len(configsToUse)); err != nil {
+				return err
+			}
+
+			// Messages go to stderr, the plan to stdout
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			out.Divider()
+			out.Section("Prune")
+			if dryRun {
+				out.WarningMsg("DRY RUN - No versions will be deleted")
+			}
+			out.Divider()
+
+			items := make([]output.PruneItem, 0)
+			tally := newOutcome(require, len(configsToUse))
+			var plans []prunePlan
+			var deleted, failed int
+			var freed int64
+			now := time.Now()
+
+			// Plan every profile before deleting anything
+			for _, name := range sortedProfileNames(configsToUse) {
+				if failFast && tally.failed > 0 {
+					out.WarningMsg(fmt.Sprintf("Skipping %s (--fail-fast)", name))
+					continue
+				}
+				obsCfg := configsToUse[name]
+				policy := *obsCfg.Retention
+				out.Subsection(fmt.Sprintf("[%s] %s", name, policy.String()))
+
+				client, err := newClient(obsCfg)
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					tally.failure(err)
+					continue
+				}
+
+				versions, err := client.ListVersionsWithOptions(obsclient.ListOptions{})
+				if err != nil {
+					out.ErrorMsg(fmt.Sprintf("Failed to list versions: %v", err))
+					tally.failure(err)
+					continue
+				}
+
+				var isTagged func(obsclient.VersionGroup) (bool, error)
+				if policy.KeepTagged {
+					isTagged = func(g obsclient.VersionGroup) (bool, error) {
+						tag, err := client.VersionTag(g)
+						return tag != "", err
+					}
+				}
+				decisions, err := retention.Plan(obsclient.GroupVersions(versions), policy, now, isTagged)
+				if err == nil {
+					err = protectDecisions(decisions, obsCfg.Protect, client.VersionTag)
+				}
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					tally.failure(err)
+					continue
+				}
+
+				// Print the plan
+				plan := prunePlan{profile: name, client: client, soft: obsCfg.SoftDelete}
+				for _, d := range decisions {
+					item := pruneItem(name, d, formatter)
+					if d.Keep {
+						out.Printf(styled.Muted, "  keep    %s (%s)\n", d.Group.Prefix, item.Reason)
+						items = append(items, item)
+						continue
+					}
+					out.Printf(styled.Warning, "  delete  %s (%d files, %s)\n", d.Group.Prefix, item.Files, item.Size)
+					plan.items = append(plan.items, item)
+					plan.groups = append(plan.groups, d.Group)
+				}
+				if len(plan.items) == 0 {
+					out.Println(styled.Muted, "  Nothing to prune")
+					tally.success()
+					continue
+				}
+				if dryRun {
+					for _, item := range plan.items {
+						freed += item.SizeBytes
+					}
+					items = append(items, plan.items...)
+					tally.success()
+					continue
+				}
+				plans = append(plans, plan)
+			}
+
+			if len(plans) > 0 {
+				var versions, objects int
+				var size int64
+				for _, plan := range plans {
+					for _, item := range plan.items {
+						versions++
+						objects += item.Files
+						size += item.SizeBytes
+					}
+				}
+				out.Spacer()
+				question := fmt.Sprintf("Delete %d version(s), %d object(s), %s from %d profile(s)?", versions, objects, formatter.FormatSize(size), len(plans))
+				if err := confirm(cmd, "prune", question, yes); err != nil {
+					return err
+				}
+			}
+
+			// Delete, several versions at a time
+			for _, plan := range plans {
+				if failFast && tally.failed > 0 {
+					out.WarningMsg(fmt.Sprintf("Skipping %s (--fail-fast)", plan.profile))
+					continue
+				}
+				out.Subsection("[" + plan.profile + "]")
+
+				var profileErrs []error
+				results := deleteVersionGroups(plan.client, plan.groups, concurrency, plan.soft, now)
+				for i, g := range plan.groups {
+					item := plan.items[i]
+					result := results[i]
+					if result.Success {
+						item.Action = "deleted"
+						deleted++
+						freed += item.SizeBytes
+						if plan.soft {
+							item.Action = "trashed"
+							out.SuccessMsg(fmt.Sprintf("Moved to trash: %s", g.Prefix))
+						} else {
+							out.SuccessMsg(fmt.Sprintf("Deleted: %s", g.Prefix))
+						}
+					} else {
+						item.Action = "failed"
+						item.Error = result.Error
+						item.Remaining = remainingKeys(result)
+						failed++
+						profileErrs = append(profileErrs, result.Err)
+						out.ErrorMsg(fmt.Sprintf("Failed: %s (%s)", g.Prefix, result.Error))
+						printRemaining(out, result)
+					}
+					items = append(items, item)
+				}
+				if len(profileErrs) > 0 {
+					tally.failure(profileErrs...)
+				} else {
+					tally.success()
+				}
+			}
+
+			out.Section("Summary")
+			if dryRun {
+				out.KeyValue("Would free", formatter.FormatSize(freed))
+			} else {
+				out.KeyValue("Deleted", fmt.Sprintf("%d version(s)", deleted))
+				if failed > 0 {
+					out.KeyValue("Failed", fmt.Sprintf("%d version(s)", failed))
+				}
+				out.KeyValue("Freed", formatter.FormatSize(freed))
+			}
+			out.Spacer()
+
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			return tally.err("prune")
+		},
+	}
+	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles with a retention policy)")
+	cmd.Flags().Bool("dry-run", false, "Show the plan without deleting")
+	cmd.Flags().String("require", "all", "Profiles that must succeed: all, any or a number")
+	cmd.Flags().Bool("fail-fast", false, "Skip the remaining profiles after one fails")
+	cmd.Flags().Int("concurrency", obsclient.DefaultDeleteConcurrency, "Number of versions to delete at once")
+	cmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation (required when not interactive)")
+	return cmd
+}
+
+// prunePlan holds the versions of one profile that prune will delete
+type prunePlan struct {
+	profile string
+	client  *obsclient.Client
+	// groups holds the versions to delete with their listed objects
+	groups []obsclient.VersionGroup
+	// items describes each version, in the same order
+	items []output.PruneItem
+	// soft moves the versions to the trash (the profile's soft_delete)
+	soft bool
+}
+
+// protectDecisions keeps the versions the protection list names, whatever
+// the retention policy decided
+func protectDecisions(decisions []retention.Decision, protect *retention.Protection, tag func(obsclient.VersionGroup) (string, error)) error {
+	for i := range decisions {
+		d := &decisions[i]
+		if d.Keep {
+			continue
+		}
+		reason, err := protect.Protects(d.Group, tag)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			d.Keep = true
+			d.Reasons = append(d.Reasons, reason)
+		}
+	}
+	return nil
+}
+
+// pruneItem converts a retention decision for output
+func pruneItem(profile string, d retention.Decision, formatter *output.Formatter) output.PruneItem {
+	item := output.PruneItem{
+		Profile:   profile,
+		Location:  d.Location,
+		Version:   d.Group.Version,
+		Files:     len(d.Group.Files),
+		Size:      formatter.FormatSize(d.Group.TotalSize),
+		SizeBytes: d.Group.TotalSize,
+		Action:    "delete",
+		Reason:    "no rule keeps it",
+	}
+	if d.Keep {
+		item.Action = "keep"
+		item.Reason = strings.Join(d.Reasons, ", ")
+	}
+	return item
+}
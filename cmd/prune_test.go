@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/retention"
+)
+
+func TestPruneCommand(t *testing.T) {
+	cmd := NewPruneCommand()
+	if cmd.Use != "prune" {
+		t.Errorf("expected use 'prune', got '%s'", cmd.Use)
+	}
+}
+
+func TestPruneCommandExecution(t *testing.T) {
+	cmd := NewPruneCommand()
+	buf := bytes.NewBufferString("")
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--help"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute prune --help failed: %v", err)
+	}
+}
+
+func TestPruneItem(t *testing.T) {
+	g := obsclient.VersionGroup{
+		Version:      "v1.0.0-abc-20260101-120000-1",
+		Prefix:       "releases/v1.0.0-abc-20260101-120000-1/",
+		Files:        []obsclient.VersionInfo{{Key: "releases/v1.0.0-abc-20260101-120000-1/app", Values: map[string]string{"prefix": "releases"}}},
+		TotalSize:    2048,
+		LastModified: time.Now(),
+	}
+	formatter := output.NewFormatter()
+
+	item := pruneItem("prod", retention.Decision{Group: g, Location: "releases"}, formatter)
+	if item.Action != "delete" || item.Files != 1 || item.Size != "2.0 KB" || item.Location != "releases" {
+		t.Errorf("unexpected delete item: %+v", item)
+	}
+
+	item = pruneItem("prod", retention.Decision{Group: g, Keep: true, Reasons: []string{"newest 5", "tagged"}}, formatter)
+	if item.Action != "keep" || item.Reason != "newest 5, tagged" {
+		t.Errorf("unexpected keep item: %+v", item)
+	}
+}
+
+func TestProtectDecisions(t *testing.T) {
+	decisions := []retention.Decision{
+		{Group: obsclient.VersionGroup{Version: "v1.0.0-a", Prefix: "v1.0.0-a/"}},
+		{Group: obsclient.VersionGroup{Version: "v2.0.0-b", Prefix: "v2.0.0-b/"}},
+		{Group: obsclient.VersionGroup{Version: "v1.0.0-c", Prefix: "v1.0.0-c/"}, Keep: true, Reasons: []string{"newest 1"}},
+	}
+	protect := &retention.Protection{Versions: []string{"v1.*"}}
+	if err := protectDecisions(decisions, protect, nil); err != nil {
+		t.Fatalf("protectDecisions failed: %v", err)
+	}
+	if !decisions[0].Keep || decisions[0].Reasons[0] != "protected version v1.*" {
+		t.Errorf("expected v1.0.0-a to be protected, got %+v", decisions[0])
+	}
+	if decisions[1].Keep {
+		t.Errorf("expected v2.0.0-b to be deleted, got %+v", decisions[1])
+	}
+	if len(decisions[2].Reasons) != 1 {
+		t.Errorf("kept versions should not be rechecked, got %+v", decisions[2])
+	}
+}
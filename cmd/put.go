@@ -1,146 +1,440 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"obsput/pkg/config"
 	"obsput/pkg/obs"
 	"obsput/pkg/output"
+	"obsput/pkg/platform"
+	"obsput/pkg/progress"
 	"obsput/pkg/styled"
 	versionpkg "obsput/pkg/version"
-	"obsput/pkg/progress"
 
 	"github.com/spf13/cobra"
 )
 
 func NewPutCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "put <file>",
+		Use:   "put <file>...",
 		Short: "Put binary to OBS",
-		Args:  cobra.ExactArgs(1),
+		Long: `Put one or more binaries to OBS under a single version.
+
+Each file is tagged with a platform (os/arch), taken from --platform or
+inferred from the file path (e.g. myapp-linux-amd64, myapp_darwin_arm64.tar.gz,
+build/v1/windows/amd64/myapp.exe). The platform fills {os} and {arch} in the key
+template and is stored in the object metadata.
+
+Examples:
+  # Upload a multi-arch set under one version
+  obsput put dist/myapp-linux-amd64 dist/myapp-darwin-arm64 dist/myapp-windows-amd64.exe
+
+  # Tag a file explicitly
+  obsput put ./myapp --platform linux/arm64`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filePath := args[0]
 			prefix, _ := cmd.Flags().GetString("prefix")
 			profile, _ := cmd.Flags().GetString("profile")
+			versionFormat, _ := cmd.Flags().GetString("version-format")
+			buildNumberProfile, _ := cmd.Flags().GetString("build-number-profile")
+			project, _ := cmd.Flags().GetString("project")
+			platforms, _ := cmd.Flags().GetStringSlice("platform")
+			requireFlag, _ := cmd.Flags().GetString("require")
+			failFast, _ := cmd.Flags().GetBool("fail-fast")
+			ciFlag, _ := cmd.Flags().GetString("ci")
+			dotenvPath, _ := cmd.Flags().GetString("ci-dotenv")
 
-			// Check file exists
-			fileInfo, err := os.Stat(filePath)
-			if os.IsNotExist(err) {
-				return fmt.Errorf("file not found: %s", filePath)
+			require, err := parseRequire(requireFlag)
+			if err != nil {
+				return err
+			}
+			if err := versionpkg.ValidateFormat(versionFormat); err != nil {
+				return err
 			}
 
-			// Generate version
-			gen := versionpkg.NewGenerator()
-			ver := gen.Generate()
-
-			// Load config
-			cfg, err := config.LoadOrInit()
+			// Check files exist and resolve their platforms
+			files, err := resolvePutFiles(args, platforms)
 			if err != nil {
-				return fmt.Errorf("load config failed: %v\nRun: obsput obs add --name prod --endpoint \"xxx\" --bucket \"xxx\" --ak \"xxx\" --sk \"xxx\"", err)
+				return err
 			}
 
-			if len(cfg.Configs) == 0 {
-				return fmt.Errorf("No OBS configurations configured\n\nConfig file: %s\n\nAdd OBS:\n  obsput obs add --name prod --endpoint \"obs.xxx.com\" --bucket \"bucket\" --ak \"xxx\" --sk \"xxx\"", getConfigPath())
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			configsToUse, err := profilesOf(cfg, profile)
+			if err != nil {
+				return err
+			}
+			if err := require.check(len(configsToUse)); err != nil {
+				return err
 			}
 
-			// Determine which configs to put to
-			var configsToUse map[string]*config.OBS
-			if profile != "" {
-				// Use specific profile
-				obsCfg := cfg.GetOBS(profile)
-				if obsCfg == nil {
-					return fmt.Errorf("profile '%s' not found in config\n\nRun: obsput obs list", profile)
+			// Generate version
+			gen := versionpkg.NewGenerator()
+			if strings.Contains(versionFormat, "{build}") {
+				counterProfile := resolveBuildNumberProfile(buildNumberProfile, profile, cfg)
+				counterCfg := cfg.GetOBS(counterProfile)
+				if counterCfg == nil {
+					return configError(fmt.Errorf("build number profile '%s' not found in config\n\nRun: obsput obs list", counterProfile))
 				}
-				configsToUse = map[string]*config.OBS{
-					profile: obsCfg,
+				counterClient, err := newClient(counterCfg)
+				if err != nil {
+					return configError(err)
 				}
-			} else {
-				// Put to all configs
-				configsToUse = cfg.Configs
+				gen.SetBuildNumberSource(counterClient)
+			}
+			ci, err := resolveCI(ciFlag, gen.BuildInfo())
+			if err != nil {
+				return err
+			}
+			ver, err := gen.GenerateFormat(versionFormat)
+			if err != nil {
+				return withExitCode(kindExitCode(obs.Classify(err)), fmt.Errorf("generate version failed: %w", err))
 			}
 
-			// Create styled output
-			out := styled.NewOutput()
-			formatter := output.NewFormatter()
+			if err := checkUploadKeys(configsToUse, files, project, gen.Branch(), ver, prefix); err != nil {
+				return configError(err)
+			}
+
+			// Progress and messages go to stderr, results to stdout
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
 
 			// Print header
 			out.Divider()
 			out.Section("Upload")
-			out.KeyValue("File", filePath)
+			for _, f := range files {
+				if f.Platform.IsZero() {
+					out.KeyValue("File", f.Path)
+				} else {
+					out.KeyValue("File", fmt.Sprintf("%s (%s)", f.Path, f.Platform))
+				}
+			}
 			out.KeyValue("Version", ver)
+			metadata := make(map[string]string)
+			if buildInfo := gen.BuildInfo(); buildInfo != nil {
+				metadata = buildInfo.Metadata()
+				out.KeyValue("CI", buildInfo.Provider)
+				if buildInfo.Branch != "" {
+					out.KeyValue("Branch", buildInfo.Branch)
+				}
+				if buildInfo.PipelineURL != "" {
+					out.KeyValue("Pipeline", buildInfo.PipelineURL)
+				}
+			}
+			// Tagged builds are kept by retention policies with keep_tagged
+			if tag := gen.Tag(); tag != "" {
+				metadata[obs.TagMetadataKey] = tag
+				out.KeyValue("Tag", tag)
+			}
 			out.Divider()
 
-			// Create progress bar
-			pb := progress.New(fileInfo.Size())
-
 			// Put to selected OBS configs
 			successCount := 0
 			failCount := 0
+			items := make([]output.UploadItem, 0, len(configsToUse)*len(files))
+			tally := newOutcome(require, len(configsToUse))
 
-			for name, obsCfg := range configsToUse {
+			for _, name := range sortedProfileNames(configsToUse) {
+				if failFast && tally.failed > 0 {
+					out.WarningMsg(fmt.Sprintf("Skipping %s (--fail-fast)", name))
+					for _, f := range files {
+						items = append(items, output.UploadItem{Profile: name, File: f.Path, Platform: platformString(f.Platform), Version: ver, Status: "skipped"})
+					}
+					continue
+				}
+				obsCfg := configsToUse[name]
 				out.Subsection("[" + name + "]")
 
-				client := obs.NewClient(obsCfg.Endpoint, obsCfg.Bucket, obsCfg.AK, obsCfg.SK)
+				client, err := newClient(obsCfg)
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					for _, f := range files {
+						items = append(items, failedUploadItem(name, f, ver, err.Error()))
+					}
+					failCount += len(files)
+					tally.failure(err)
+					continue
+				}
+				var profileErrs []error
+				profileValues := client.KeyValues
 
-				startTime := time.Now()
+				for _, f := range files {
+					client.KeyValues = keyValues(profileValues, project, gen.Branch(), f.Platform)
 
-				result, err := client.UploadFile(filePath, ver, prefix, func(bytes int64) {
-					pb.SetTotal(fileInfo.Size())
-					pb.Increment(bytes - pb.Current())
-					pb.Render()
-				})
+					// Create progress bar
+					pb := progress.New(f.Size)
+					pb.SetWriter(cmd.ErrOrStderr())
+					startTime := time.Now()
 
-				// Clear progress bar line
-				cmd.Println()
+					res, err := client.UploadFileWithMetadata(f.Path, ver, prefix, fileMetadata(metadata, f.Platform), func(bytes int64) {
+						pb.SetTotal(f.Size)
+						pb.Increment(bytes - pb.Current())
+						pb.Render()
+					})
 
-				if err != nil {
-					out.ErrorMsg(fmt.Sprintf("Upload failed: %v", err))
-					failCount++
-					continue
-				}
+					// Clear progress bar line
+					out.Spacer()
 
-				if result.Success {
-					// Extract filename from key
-					filename := client.ExtractFilenameFromKey(result.Key)
-					// Print result in a styled box
-					content := map[string]string{
-						"URL":        result.URL,
-						"Size":       formatter.FormatSize(result.Size),
-						"MD5":        result.MD5,
-						"Clean URL":  obs.CleanURL(result.SignedURL),
+					if err == nil && !res.Success {
+						err = res.Err
+						if err == nil {
+							err = errors.New(res.Error)
+						}
+					}
+					if err != nil {
+						out.ErrorMsg(fmt.Sprintf("Upload failed: %v", err))
+						items = append(items, failedUploadItem(name, f, ver, err.Error()))
+						profileErrs = append(profileErrs, err)
+						failCount++
+						continue
 					}
-					if result.Size > 0 {
-						elapsed := time.Since(startTime)
-						speed := float64(result.Size) / elapsed.Seconds()
-						content["Speed"] = formatter.FormatSize(int64(speed)) + "/s"
+
+					filename := client.ExtractFilenameFromKey(res.Key)
+					cleanURL := obs.CleanURL(res.SignedURL)
+					item := output.UploadItem{
+						Profile:  name,
+						File:     f.Path,
+						Platform: platformString(f.Platform),
+						Version:  res.Version,
+						Key:      res.Key,
+						URL:      res.URL,
+						CleanURL: cleanURL,
+						Size:     formatter.FormatSize(res.Size),
+						MD5:      res.MD5,
+						Status:   "uploaded",
 					}
-					out.PrintBox("Upload Result", content)
+					items = append(items, item)
 
+					msg := fmt.Sprintf("Uploaded %s to %s (%s", filename, obsCfg.Bucket, item.Size)
+					if res.Size > 0 {
+						speed := float64(res.Size) / time.Since(startTime).Seconds()
+						msg += ", " + formatter.FormatSize(int64(speed)) + "/s"
+					}
+					out.SuccessMsg(msg + ")")
 					out.Println(styled.Header, "Download Commands:")
-					cleanURL := obs.CleanURL(result.SignedURL)
 					out.Printf(styled.Muted, "  curl -k -o %s %s\n", filename, cleanURL)
 					out.Printf(styled.Muted, "  wget --no-check-certificate -O %s %s\n", filename, cleanURL)
-					out.Spacer()
-					out.SuccessMsg(fmt.Sprintf("Uploaded to %s", obsCfg.Bucket))
 					successCount++
+				}
+				if len(profileErrs) > 0 {
+					tally.failure(profileErrs...)
 				} else {
-					out.ErrorMsg(result.Error)
-					failCount++
+					tally.success()
 				}
-				cmd.Println()
 			}
 
 			out.Section("Summary")
 			out.Summary(successCount, failCount)
+			out.Spacer()
 
-			return nil
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			reportErr := writeCIReport(ci, dotenvPath, items)
+			if err := tally.err("upload"); err != nil {
+				return err
+			}
+			return reportErr
 		},
 	}
 	cmd.Flags().String("prefix", "", "Path prefix for put")
 	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
+	cmd.Flags().StringSlice("platform", nil, "Platform (os/arch) of each file, in argument order (default: inferred from file name)")
+	cmd.Flags().String("project", "", "Value for {project} in the key template (default: profile project)")
+	cmd.Flags().String("version-format", versionpkg.DefaultFormat, "Version layout ({commit}, {date}, {time}, {counter}, {build})")
+	cmd.Flags().String("require", "all", "Profiles that must succeed: all, any or a number")
+	cmd.Flags().Bool("fail-fast", false, "Skip the remaining profiles after one fails")
+	cmd.Flags().String("ci", "auto", "Write results for a CI system: github, gitlab, none or auto (detect)")
+	cmd.Flags().String("ci-dotenv", output.DefaultDotenvFile, "GitLab dotenv report file written in gitlab mode")
+	cmd.Flags().String("build-number-profile", "", "Profile whose bucket holds the {build} counter (default: --profile or first profile)")
 	return cmd
 }
 
+// putFile is a file to upload with its resolved platform
+type putFile struct {
+	Path     string
+	Size     int64
+	Platform platform.Platform
+}
+
+// resolvePutFiles checks the files exist and assigns platforms, either
+// positionally from --platform or inferred from each path
+func resolvePutFiles(paths, platforms []string) ([]putFile, error) {
+	if len(platforms) > 0 && len(platforms) != len(paths) {
+		return nil, fmt.Errorf("got %d --platform values for %d files; give one per file, in order", len(platforms), len(paths))
+	}
+
+	files := make([]putFile, 0, len(paths))
+	for i, path := range paths {
+		fileInfo, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", path)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		f := putFile{Path: path, Size: fileInfo.Size()}
+		if len(platforms) > 0 {
+			p, err := platform.Parse(platforms[i])
+			if err != nil {
+				return nil, err
+			}
+			f.Platform = p
+		} else if p, ok := platform.Infer(path); ok {
+			f.Platform = p
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// checkUploadKeys renders the key of every file for every profile before
+// anything is uploaded, and fails when two files would overwrite each other
+func checkUploadKeys(configs map[string]*config.OBS, files []putFile, project, branch, version, prefix string) error {
+	for _, name := range sortedProfileNames(configs) {
+		client, err := newClient(configs[name])
+		if err != nil {
+			// Reported for the profile when uploading
+			continue
+		}
+		profileValues := client.KeyValues
+		seen := make(map[string]string, len(files))
+		for _, f := range files {
+			client.KeyValues = keyValues(profileValues, project, branch, f.Platform)
+			key, err := client.GetUploadKey(prefix, version, filepath.Base(f.Path))
+			if err != nil {
+				return fmt.Errorf("profile '%s': %v", name, err)
+			}
+			if other, ok := seen[key]; ok {
+				return fmt.Errorf("%s and %s would both be uploaded to %s in profile '%s'; rename them or add {os} and {arch} to the key template", other, f.Path, key, name)
+			}
+			seen[key] = f.Path
+		}
+	}
+	return nil
+}
+
+// keyValues merges the put-time key template values into the profile's values
+func keyValues(base map[string]string, project, branch string, p platform.Platform) map[string]string {
+	values := map[string]string{
+		"branch": branch,
+		"os":     p.OS,
+		"arch":   p.Arch,
+	}
+	for k, v := range base {
+		values[k] = v
+	}
+	if project != "" {
+		values["project"] = project
+	}
+	return values
+}
+
+// failedUploadItem records a file that could not be uploaded to a profile
+func failedUploadItem(profile string, f putFile, version, errMsg string) output.UploadItem {
+	return output.UploadItem{
+		Profile:  profile,
+		File:     f.Path,
+		Platform: platformString(f.Platform),
+		Version:  version,
+		Status:   "failed",
+		Error:    errMsg,
+	}
+}
+
+// platformString returns "os/arch", or "" when the platform is unknown
+func platformString(p platform.Platform) string {
+	if p.IsZero() {
+		return ""
+	}
+	return p.String()
+}
+
+// fileMetadata adds the file's platform to the shared upload metadata
+func fileMetadata(metadata map[string]string, p platform.Platform) map[string]string {
+	if p.IsZero() {
+		return metadata
+	}
+	merged := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	merged[obs.PlatformMetadataKey] = p.String()
+	return merged
+}
+
+// resolveCI picks the CI system to report results to. "auto" uses the
+// detected provider when it is GitLab CI, or GitHub Actions with its output
+// files available.
+func resolveCI(ci string, buildInfo *versionpkg.BuildInfo) (string, error) {
+	switch ci {
+	case output.CIGitHub, output.CIGitLab, output.CINone:
+		return ci, nil
+	case "", "auto":
+		if buildInfo == nil {
+			return output.CINone, nil
+		}
+		switch buildInfo.Provider {
+		case output.CIGitHub:
+			if os.Getenv("GITHUB_OUTPUT") != "" || os.Getenv("GITHUB_STEP_SUMMARY") != "" {
+				return output.CIGitHub, nil
+			}
+		case output.CIGitLab:
+			return output.CIGitLab, nil
+		}
+		return output.CINone, nil
+	}
+	return "", fmt.Errorf("invalid --ci %q (use github, gitlab, none or auto)", ci)
+}
+
+// writeCIReport writes upload results where the CI system picks them up:
+// step outputs and the job summary on GitHub, a dotenv report on GitLab
+func writeCIReport(ci, dotenvPath string, items []output.UploadItem) error {
+	switch ci {
+	case output.CIGitHub:
+		outputPath := os.Getenv("GITHUB_OUTPUT")
+		summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+		if outputPath == "" && summaryPath == "" {
+			return fmt.Errorf("--ci github: neither GITHUB_OUTPUT nor GITHUB_STEP_SUMMARY is set")
+		}
+		if outputPath != "" {
+			if err := output.WriteGitHubOutput(outputPath, items); err != nil {
+				return fmt.Errorf("write GitHub outputs: %v", err)
+			}
+		}
+		if summaryPath != "" {
+			if err := output.WriteGitHubStepSummary(summaryPath, items); err != nil {
+				return fmt.Errorf("write GitHub step summary: %v", err)
+			}
+		}
+	case output.CIGitLab:
+		if err := output.WriteDotenv(dotenvPath, items); err != nil {
+			return fmt.Errorf("write dotenv report: %v", err)
+		}
+	}
+	return nil
+}
+
+// resolveBuildNumberProfile picks the profile whose bucket stores the shared
+// build counter, so every upload of one build uses the same number
+func resolveBuildNumberProfile(buildNumberProfile, profile string, cfg *config.Config) string {
+	if buildNumberProfile != "" {
+		return buildNumberProfile
+	}
+	if profile != "" {
+		return profile
+	}
+	return sortedProfileNames(cfg.Configs)[0]
+}
+
 func init() {}
@@ -12,7 +12,7 @@ func TestPutWithProgressBar(t *testing.T) {
 
 	// Test that put command accepts progress display
 	// For now, verify command structure
-	if cmd.Use != "put <file>" {
-		t.Errorf("expected 'put <file>', got '%s'", cmd.Use)
+	if cmd.Use != "put <file>..." {
+		t.Errorf("expected 'put <file>...', got '%s'", cmd.Use)
 	}
 }
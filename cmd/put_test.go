@@ -2,13 +2,20 @@ package cmd
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"obsput/pkg/config"
+	"obsput/pkg/platform"
+	versionpkg "obsput/pkg/version"
 )
 
 func TestPutCommand(t *testing.T) {
 	cmd := NewPutCommand()
-	if cmd.Use != "put <file>" {
-		t.Errorf("expected use 'put <file>', got '%s'", cmd.Use)
+	if cmd.Use != "put <file>..." {
+		t.Errorf("expected use 'put <file>...', got '%s'", cmd.Use)
 	}
 }
 
@@ -21,3 +28,97 @@ func TestPutCommandExecution(t *testing.T) {
 		t.Fatalf("execute put --help failed: %v", err)
 	}
 }
+
+func TestResolvePutFiles(t *testing.T) {
+	dir := t.TempDir()
+	linux := filepath.Join(dir, "app-linux-amd64")
+	plain := filepath.Join(dir, "app")
+	for _, p := range []string{linux, plain} {
+		if err := os.WriteFile(p, []byte("bin"), 0644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+	}
+
+	files, err := resolvePutFiles([]string{linux, plain}, nil)
+	if err != nil {
+		t.Fatalf("resolvePutFiles failed: %v", err)
+	}
+	if files[0].Platform.String() != "linux/amd64" {
+		t.Errorf("expected inferred linux/amd64, got %s", files[0].Platform)
+	}
+	if !files[1].Platform.IsZero() {
+		t.Errorf("expected no platform for plain file, got %s", files[1].Platform)
+	}
+
+	files, err = resolvePutFiles([]string{plain}, []string{"darwin/arm64"})
+	if err != nil {
+		t.Fatalf("resolvePutFiles failed: %v", err)
+	}
+	if files[0].Platform.String() != "darwin/arm64" {
+		t.Errorf("expected darwin/arm64, got %s", files[0].Platform)
+	}
+
+	if _, err := resolvePutFiles([]string{linux, plain}, []string{"linux/amd64"}); err == nil {
+		t.Error("expected error when --platform count does not match files")
+	}
+}
+
+func TestCheckUploadKeys(t *testing.T) {
+	dir := t.TempDir()
+	var files []putFile
+	for _, p := range []string{"linux/amd64", "linux/arm64"} {
+		path := filepath.Join(dir, strings.ReplaceAll(p, "/", "-"), "app")
+		plat, _ := platform.Parse(p)
+		files = append(files, putFile{Path: path, Platform: plat})
+	}
+	ver := "v1.0.0-abc-20260101-120000-1"
+
+	plain := map[string]*config.OBS{"prod": {Name: "prod", Endpoint: "obs.test.com", Bucket: "bucket", AK: "ak", SK: "sk"}}
+	err := checkUploadKeys(plain, files, "", "", ver, "")
+	if err == nil || !strings.Contains(err.Error(), "would both be uploaded to "+ver+"/app") {
+		t.Errorf("expected a duplicate key error, got %v", err)
+	}
+
+	byPlatform := map[string]*config.OBS{"prod": {Name: "prod", Endpoint: "obs.test.com", Bucket: "bucket", AK: "ak", SK: "sk", KeyTemplate: "{version}/{os}/{arch}/{filename}"}}
+	if err := checkUploadKeys(byPlatform, files, "", "", ver, ""); err != nil {
+		t.Errorf("keys with {os}/{arch} should not collide: %v", err)
+	}
+}
+
+func TestResolveCI(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	github := &versionpkg.BuildInfo{Provider: "github"}
+	gitlab := &versionpkg.BuildInfo{Provider: "gitlab"}
+	jenkins := &versionpkg.BuildInfo{Provider: "jenkins"}
+
+	tests := []struct {
+		flag      string
+		buildInfo *versionpkg.BuildInfo
+		want      string
+	}{
+		{"auto", nil, "none"},
+		{"auto", gitlab, "gitlab"},
+		{"auto", jenkins, "none"},
+		{"auto", github, "none"}, // no output files outside a real runner
+		{"github", nil, "github"},
+		{"none", gitlab, "none"},
+	}
+	for _, tt := range tests {
+		got, err := resolveCI(tt.flag, tt.buildInfo)
+		if err != nil {
+			t.Fatalf("resolveCI(%q) failed: %v", tt.flag, err)
+		}
+		if got != tt.want {
+			t.Errorf("resolveCI(%q, %v) = %q, want %q", tt.flag, tt.buildInfo, got, tt.want)
+		}
+	}
+
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "output"))
+	if got, _ := resolveCI("auto", github); got != "github" {
+		t.Errorf("expected github with GITHUB_OUTPUT set, got %q", got)
+	}
+	if _, err := resolveCI("jenkins", nil); err == nil {
+		t.Error("expected error for unsupported --ci value")
+	}
+}
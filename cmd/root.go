@@ -3,8 +3,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 
 	"obsput/pkg/config"
+	"obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/styled"
 
 	"github.com/spf13/cobra"
 )
@@ -19,14 +23,26 @@ func NewRootCommand() *cobra.Command {
 		Short:   "Upload binaries to Huawei Cloud OBS",
 		Version: version,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			fromEnv, _ := cmd.Flags().GetBool("profile-from-env")
+			config.UseEnv(fromEnv)
+			passphraseFile, _ := cmd.Flags().GetString("passphrase-file")
+			config.UsePassphraseFile(passphraseFile)
+			config.SetPrompt(passphrasePrompt(cmd))
 			return nil
 		},
 	}
+	cmd.PersistentFlags().StringP("output", "o", output.FormatTable, "Result format (table/json/yaml/csv)")
+	cmd.PersistentFlags().String("format", "", "Go template applied to each result, e.g. '{{.Version}} {{.URL}}'")
+	cmd.PersistentFlags().Bool("profile-from-env", false, "Use a single profile from OBSPUT_ENDPOINT, OBSPUT_BUCKET and credential variables instead of the config file")
+	cmd.PersistentFlags().String("passphrase-file", "", "File holding the passphrase of a locked config (see obs lock)")
 	cmd.AddCommand(NewOBSCommand())
 	cmd.AddCommand(NewPutCommand())
 	cmd.AddCommand(NewListCommand())
 	cmd.AddCommand(NewDeleteCommand())
 	cmd.AddCommand(NewDownloadCommand())
+	cmd.AddCommand(NewPruneCommand())
+	cmd.AddCommand(NewTrashCommand())
+	cmd.AddCommand(NewDoctorCommand())
 	return cmd
 }
 
@@ -36,10 +52,111 @@ func Execute() {
 	cmd.SilenceUsage = true
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		if hint := errorHint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, hint)
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
+// newClient creates an OBS client configured from a profile
+func newClient(obsCfg *config.OBS) (*obs.Client, error) {
+	ak, sk, err := obsCfg.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	client := obs.NewClient(obsCfg.Endpoint, obsCfg.Bucket, ak, sk)
+	if client.SecurityToken, _, err = obsCfg.Token(); err != nil {
+		return nil, configError(err)
+	}
+	if obsCfg.CredentialProcess != "" {
+		client.Provider = obs.NewProcessProvider(obsCfg.CredentialProcess)
+	}
+	client.TLS = obsCfg.TLS
+	client.HTTP = obsCfg.HTTP
+	if err := client.SetKeyTemplate(obsCfg.GetKeyTemplate()); err != nil {
+		return nil, fmt.Errorf("profile '%s': %v", obsCfg.Name, err)
+	}
+	if obsCfg.Project != "" {
+		client.KeyValues = map[string]string{"project": obsCfg.Project}
+	}
+	return client, nil
+}
+
+// loadConfig loads the config for a command
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadOrInit()
+	if err != nil {
+		return nil, configError(fmt.Errorf("load config failed: %v\nRun: obsput obs add --name prod --endpoint \"xxx\" --bucket \"xxx\" --ak \"xxx\" --sk \"xxx\"", err))
+	}
+	return cfg, nil
+}
+
+// selectProfiles loads the config and selects the profile, or all profiles
+func selectProfiles(profile string) (map[string]*config.OBS, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return profilesOf(cfg, profile)
+}
+
+// profilesOf selects the profile from a loaded config, or all profiles
+func profilesOf(cfg *config.Config, profile string) (map[string]*config.OBS, error) {
+	if len(cfg.Configs) == 0 {
+		return nil, configError(fmt.Errorf("no OBS configurations configured\n\nConfig file: %s\n\nRun: obsput obs add --name prod --endpoint \"obs.xxx.com\" --bucket \"bucket\" --ak \"xxx\" --sk \"xxx\"", getConfigPath()))
+	}
+	if profile == "" {
+		return cfg.Configs, nil
+	}
+	obsCfg := cfg.GetOBS(profile)
+	if obsCfg == nil {
+		return nil, configError(fmt.Errorf("profile '%s' not found in config\n\nRun: obsput obs list", profile))
+	}
+	return map[string]*config.OBS{profile: obsCfg}, nil
+}
+
+// profileClient returns a client for a named profile
+func profileClient(name string) (*obs.Client, error) {
+	configs, err := selectProfiles(name)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(configs[name])
+}
+
+// newOutputs returns the styled writer for progress and messages, which goes
+// to stderr, and the formatter for command results, which goes to stdout in
+// the format selected by --output and --format
+func newOutputs(cmd *cobra.Command) (*styled.Output, *output.Formatter, error) {
+	var format, tmpl string
+	if f := cmd.Flags().Lookup("output"); f != nil {
+		format = f.Value.String()
+	}
+	if f := cmd.Flags().Lookup("format"); f != nil {
+		tmpl = f.Value.String()
+	}
+
+	formatter := output.NewFormatter()
+	formatter.SetOutput(cmd.OutOrStdout())
+	if err := formatter.SetFormat(format, tmpl); err != nil {
+		return nil, nil, err
+	}
+	out := styled.NewOutput()
+	out.SetOutput(cmd.ErrOrStderr())
+	return out, formatter, nil
+}
+
+// sortedProfileNames returns profile names in a stable order for output
+func sortedProfileNames(configs map[string]*config.OBS) []string {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func getConfigPath() string {
 	path, _ := config.GetConfigPath()
 	return path
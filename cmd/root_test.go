@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"obsput/pkg/config"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -55,3 +57,48 @@ func TestGetConfigPathNotInHome(t *testing.T) {
 		t.Error("config path should not be in home directory")
 	}
 }
+
+func TestProfileFromEnv(t *testing.T) {
+	t.Cleanup(func() { config.UseEnv(false) })
+	t.Setenv(config.EnvProfile, "")
+	t.Setenv(config.EnvEndpoint, "obs.test.com")
+	t.Setenv(config.EnvBucket, "ci-bucket")
+	t.Setenv("OBSPUT_ENV_AK", "")
+	t.Setenv("OBSPUT_ENV_SK", "")
+	t.Setenv(config.EnvAccessKey, "AKFROMENVIRONMENT")
+	t.Setenv(config.EnvSecretKey, "secret-from-environment")
+
+	root := NewRootCommand()
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"--profile-from-env", "obs", "get", "env", "--offline", "-o", "json"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("obs get failed: %v", err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, `"Bucket": "ci-bucket"`) || !strings.Contains(got, `"AKSource": "env OBS_ACCESS_KEY_ID"`) {
+		t.Errorf("unexpected output %s", got)
+	}
+	if strings.Contains(got, "secret-from-environment") {
+		t.Errorf("secret key not masked: %s", got)
+	}
+
+	root = NewRootCommand()
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"--profile-from-env", "obs", "add", "--name", "x", "--endpoint", "obs.test.com", "--bucket", "b", "--ak", "a", "--sk", "s"})
+	if err := root.Execute(); err == nil {
+		t.Error("obs add should not save a profile from the environment")
+	}
+}
+
+func TestNewClientRejectsUnsetTokenVariable(t *testing.T) {
+	t.Setenv("OBSPUT_PROD_AK", "")
+	t.Setenv("CI_TOKEN", "")
+	obsCfg := &config.OBS{Name: "prod", Endpoint: "obs.test.com", Bucket: "bucket", AK: "ak", SK: "sk", SecurityTokenEnv: "CI_TOKEN"}
+	_, err := newClient(obsCfg)
+	if exitCode(err) != ExitConfig || !strings.Contains(err.Error(), "CI_TOKEN") {
+		t.Errorf("expected a config error naming CI_TOKEN, got exit code %d (%v)", exitCode(err), err)
+	}
+}
@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"obsput/pkg/config"
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+	"obsput/pkg/styled"
+
+	"github.com/spf13/cobra"
+)
+
+func NewTrashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore and empty soft-deleted versions",
+		Long: `Manage versions removed with "obsput delete --soft" or by a profile with
+soft_delete enabled. They are kept under .trash/<timestamp>/ in the bucket.`,
+	}
+	cmd.AddCommand(NewTrashListCommand())
+	cmd.AddCommand(NewTrashRestoreCommand())
+	cmd.AddCommand(NewTrashEmptyCommand())
+	return cmd
+}
+
+func NewTrashListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List soft-deleted versions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, _ := cmd.Flags().GetString("profile")
+
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
+			}
+
+			// Messages go to stderr, trash entries to stdout
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			out.Section("Trash")
+			out.Divider()
+
+			items := make([]output.TrashItem, 0)
+			tally := newOutcome(requirement{all: true}, len(configsToUse))
+			for _, name := range sortedProfileNames(configsToUse) {
+				entries, err := listTrash(configsToUse[name])
+				if err != nil {
+					out.ErrorMsg(fmt.Sprintf("[%s] Failed to list trash: %v", name, err))
+					tally.failure(err)
+					continue
+				}
+				tally.success()
+				for _, e := range entries {
+					items = append(items, trashItem(name, e, formatter))
+				}
+			}
+			if len(items) == 0 {
+				out.Println(styled.Muted, "  Trash is empty")
+			}
+
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			return tally.err("list trash")
+		},
+	}
+	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
+	return cmd
+}
+
+func NewTrashRestoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <version>",
+		Short: "Restore a soft-deleted version",
+		Long: `Copy a soft-deleted version back to its original keys and remove it from
+the trash. The version must match exactly; when it was deleted more than once,
+the most recent copy is restored. Restoring over objects that exist again at
+the original keys asks for confirmation first.
+
+Examples:
+  obsput trash restore v1.0.0-abc123-20260214-153045-1
+  obsput trash restore v1.0.0-abc123-20260214-153045-1 --profile prod --yes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version := args[0]
+			profile, _ := cmd.Flags().GetString("profile")
+			yes, _ := cmd.Flags().GetBool("yes")
+
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			out.Divider()
+			out.Section(fmt.Sprintf("Restore %s", version))
+			out.Divider()
+
+			// Plan every profile before restoring anything
+			type restorePlan struct {
+				profile string
+				client  *obsclient.Client
+				entries []obsclient.TrashEntry
+			}
+			var plans []restorePlan
+			var overwrites int
+			tally := newOutcome(requirement{all: true}, len(configsToUse))
+			for _, name := range sortedProfileNames(configsToUse) {
+				out.Subsection("[" + name + "]")
+
+				client, err := newClient(configsToUse[name])
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					tally.failure(err)
+					continue
+				}
+				entries, err := client.ListTrash()
+				if err != nil {
+					out.ErrorMsg(fmt.Sprintf("Failed to list trash: %v", err))
+					tally.failure(err)
+					continue
+				}
+
+				// Entries are newest first: keep the first copy of each version
+				seen := make(map[string]bool)
+				plan := restorePlan{profile: name, client: client}
+				var profileErrs []error
+				for _, e := range entries {
+					if e.Version != version || seen[e.Prefix] {
+						continue
+					}
+					seen[e.Prefix] = true
+					existing, err := client.ExistingKeys(e)
+					if err != nil {
+						out.ErrorMsg(fmt.Sprintf("Failed to check %s: %v", e.Prefix, err))
+						profileErrs = append(profileErrs, err)
+						continue
+					}
+					for _, key := range existing {
+						out.Printf(styled.Warning, "  overwrite  %s\n", key)
+					}
+					overwrites += len(existing)
+					plan.entries = append(plan.entries, e)
+				}
+				if len(seen) == 0 {
+					out.Println(styled.Muted, "  Not in trash")
+				}
+				if len(profileErrs) > 0 {
+					tally.failure(profileErrs...)
+					continue
+				}
+				if len(plan.entries) == 0 {
+					tally.success()
+					continue
+				}
+				plans = append(plans, plan)
+			}
+
+			if overwrites > 0 {
+				out.Spacer()
+				question := fmt.Sprintf("Overwrite %d existing object(s) with the trashed copies?", overwrites)
+				if err := confirm(cmd, "restore over existing objects", question, yes); err != nil {
+					return err
+				}
+			}
+
+			items := make([]output.TrashItem, 0)
+			for _, plan := range plans {
+				var profileErrs []error
+				for _, e := range plan.entries {
+					item := trashItem(plan.profile, e, formatter)
+					result := plan.client.RestoreTrash(e)
+					if result.Success {
+						item.Status = "restored"
+						out.SuccessMsg(fmt.Sprintf("[%s] Restored: %s (deleted %s)", plan.profile, e.Prefix, item.DeletedAt))
+					} else {
+						item.Status = "failed"
+						item.Error = result.Error
+						profileErrs = append(profileErrs, result.Err)
+						out.ErrorMsg(fmt.Sprintf("[%s] Failed: %s (%s)", plan.profile, e.Prefix, result.Error))
+						printRemaining(out, result)
+					}
+					items = append(items, item)
+				}
+				if len(profileErrs) > 0 {
+					tally.failure(profileErrs...)
+				} else {
+					tally.success()
+				}
+			}
+			out.Spacer()
+
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			if err := tally.err("restore"); err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				return fmt.Errorf("version %s not found in trash\n\nRun: obsput trash list", version)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
+	cmd.Flags().BoolP("yes", "y", false, "Overwrite existing objects without asking (required when not interactive)")
+	return cmd
+}
+
+func NewTrashEmptyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete soft-deleted versions",
+		Long: `Permanently delete versions from the trash.
+
+Examples:
+  # Everything deleted more than 30 days ago
+  obsput trash empty --older-than 30d
+
+  # The whole trash of one profile, from a script
+  obsput trash empty --profile prod --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, _ := cmd.Flags().GetString("profile")
+			olderThan, _ := cmd.Flags().GetString("older-than")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			yes, _ := cmd.Flags().GetBool("yes")
+
+			var cutoff time.Time
+			if olderThan != "" {
+				t, err := parseBeforeDate(olderThan)
+				if err != nil {
+					return fmt.Errorf("invalid --older-than format: %v\nUse format: YYYY-MM-DD or Nd (e.g., 30d, 24h)", err)
+				}
+				cutoff = t
+			}
+
+			configsToUse, err := selectProfiles(profile)
+			if err != nil {
+				return err
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			out.Divider()
+			out.Section("Empty Trash")
+			if dryRun {
+				out.WarningMsg("DRY RUN - Nothing will be deleted")
+			}
+			out.Divider()
+
+			// Plan every profile before deleting anything
+			type emptyPlan struct {
+				profile string
+				client  *obsclient.Client
+				entries []obsclient.TrashEntry
+			}
+			var plans []emptyPlan
+			var versions, objects int
+			var size int64
+			items := make([]output.TrashItem, 0)
+			tally := newOutcome(requirement{all: true}, len(configsToUse))
+			for _, name := range sortedProfileNames(configsToUse) {
+				out.Subsection("[" + name + "]")
+
+				client, err := newClient(configsToUse[name])
+				if err != nil {
+					out.ErrorMsg(err.Error())
+					tally.failure(err)
+					continue
+				}
+				entries, err := client.ListTrash()
+				if err != nil {
+					out.ErrorMsg(fmt.Sprintf("Failed to list trash: %v", err))
+					tally.failure(err)
+					continue
+				}
+
+				plan := emptyPlan{profile: name, client: client}
+				for _, e := range entries {
+					if !cutoff.IsZero() && !e.DeletedAt.Before(cutoff) {
+						continue
+					}
+					plan.entries = append(plan.entries, e)
+					versions++
+					objects += len(e.Keys)
+					size += e.TotalSize
+					out.Printf(styled.Warning, "  delete  %s (deleted %s, %d files, %s)\n", e.Prefix, e.DeletedAt.Format("2006-01-02 15:04"), len(e.Keys), formatter.FormatSize(e.TotalSize))
+				}
+				if len(plan.entries) == 0 {
+					out.Println(styled.Muted, "  Nothing to delete")
+					tally.success()
+					continue
+				}
+				if dryRun {
+					for _, e := range plan.entries {
+						item := trashItem(name, e, formatter)
+						item.Status = "planned"
+						items = append(items, item)
+					}
+					tally.success()
+					continue
+				}
+				plans = append(plans, plan)
+			}
+
+			if len(plans) > 0 {
+				out.Spacer()
+				question := fmt.Sprintf("Permanently delete %d version(s), %d object(s), %s from %d profile(s)?", versions, objects, formatter.FormatSize(size), len(plans))
+				if err := confirm(cmd, "empty the trash", question, yes); err != nil {
+					return err
+				}
+			}
+
+			for _, plan := range plans {
+				var profileErrs []error
+				for _, e := range plan.entries {
+					item := trashItem(plan.profile, e, formatter)
+					result := plan.client.EmptyTrash(e)
+					if result.Success {
+						item.Status = "deleted"
+						out.SuccessMsg(fmt.Sprintf("[%s] Deleted: %s", plan.profile, e.Prefix))
+					} else {
+						item.Status = "failed"
+						item.Error = result.Error
+						profileErrs = append(profileErrs, result.Err)
+						out.ErrorMsg(fmt.Sprintf("[%s] Failed: %s (%s)", plan.profile, e.Prefix, result.Error))
+						printRemaining(out, result)
+					}
+					items = append(items, item)
+				}
+				if len(profileErrs) > 0 {
+					tally.failure(profileErrs...)
+				} else {
+					tally.success()
+				}
+			}
+			out.Spacer()
+
+			if err := formatter.Render(items); err != nil {
+				return err
+			}
+			return tally.err("empty trash")
+		},
+	}
+	cmd.Flags().StringP("profile", "p", "", "OBS profile name to use (default: all profiles)")
+	cmd.Flags().String("older-than", "", "Only versions deleted before this date (YYYY-MM-DD or Nd, Nh)")
+	cmd.Flags().Bool("dry-run", false, "Show what would be deleted without deleting")
+	cmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation (required when not interactive)")
+	return cmd
+}
+
+// listTrash lists the trash of one profile
+func listTrash(obsCfg *config.OBS) ([]obsclient.TrashEntry, error) {
+	client, err := newClient(obsCfg)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListTrash()
+}
+
+// trashItem converts a trash entry for output
+func trashItem(profile string, e obsclient.TrashEntry, formatter *output.Formatter) output.TrashItem {
+	return output.TrashItem{
+		Profile:   profile,
+		Version:   e.Version,
+		Prefix:    e.Prefix,
+		DeletedAt: e.DeletedAt.Format("2006-01-02 15:04:05"),
+		Files:     len(e.Keys),
+		Size:      formatter.FormatSize(e.TotalSize),
+		SizeBytes: e.TotalSize,
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+)
+
+func TestTrashCommand(t *testing.T) {
+	cmd := NewTrashCommand()
+	want := map[string]bool{"list": true, "restore": true, "empty": true}
+	for _, c := range cmd.Commands() {
+		delete(want, c.Name())
+	}
+	if len(want) != 0 {
+		t.Errorf("missing trash subcommands: %v", want)
+	}
+}
+
+func TestTrashEmptyCommandExecution(t *testing.T) {
+	cmd := NewTrashEmptyCommand()
+	buf := bytes.NewBufferString("")
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--help"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute trash empty --help failed: %v", err)
+	}
+	for _, name := range []string{"older-than", "dry-run", "yes"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected --%s flag", name)
+		}
+	}
+}
+
+func TestTrashItem(t *testing.T) {
+	e := obsclient.TrashEntry{
+		Version:   "v1.0.0-abc-20260101-120000-1",
+		Prefix:    "releases/v1.0.0-abc-20260101-120000-1/",
+		Stamp:     "20260301-083000",
+		DeletedAt: time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC),
+		Keys:      []string{".trash/20260301-083000/releases/v1.0.0-abc-20260101-120000-1/app"},
+		TotalSize: 2048,
+	}
+	item := trashItem("prod", e, output.NewFormatter())
+	if item.DeletedAt != "2026-03-01 08:30:00" || item.Files != 1 || item.Size != "2.0 KB" || item.Prefix != e.Prefix {
+		t.Errorf("unexpected trash item: %+v", item)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+
+	obsclient "obsput/pkg/obs"
+	"obsput/pkg/output"
+
+	"github.com/spf13/cobra"
+)
+
+func NewOBSVersioningCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "versioning",
+		Short: "Manage bucket versioning",
+		Long: `With versioning enabled, OBS keeps every copy of an object when it is
+overwritten or deleted, so a bad upload can be rolled back.
+
+  obsput list --history <key>                       # all copies of an object
+  obsput download <key> --object-version <id>       # fetch an older copy`,
+	}
+	cmd.AddCommand(newOBSVersioningSetCommand("enable", "Keep every copy of overwritten and deleted objects", true))
+	cmd.AddCommand(newOBSVersioningSetCommand("suspend", "Stop keeping new copies; existing copies are kept", false))
+	cmd.AddCommand(NewOBSVersioningStatusCommand())
+	return cmd
+}
+
+// newOBSVersioningSetCommand builds enable and suspend, which only differ in
+// the state they set
+func newOBSVersioningSetCommand(use, short string, enabled bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use + " <profile>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			client, err := profileClient(name)
+			if err != nil {
+				return err
+			}
+
+			out, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.SetVersioning(enabled); err != nil {
+				return withExitCode(kindExitCode(obsclient.Classify(err)), fmt.Errorf("%s versioning of %s failed: %w", use, name, err))
+			}
+			status := obsclient.VersioningSuspended
+			if enabled {
+				status = obsclient.VersioningEnabled
+			}
+			out.SuccessMsg(fmt.Sprintf("Versioning of %s (%s): %s", name, client.Bucket, status))
+			return formatter.Render([]output.VersioningItem{{Profile: name, Bucket: client.Bucket, Status: status}})
+		},
+	}
+	return cmd
+}
+
+func NewOBSVersioningStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status <profile>",
+		Short: "Show whether a profile's bucket keeps object versions",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			client, err := profileClient(name)
+			if err != nil {
+				return err
+			}
+
+			_, formatter, err := newOutputs(cmd)
+			if err != nil {
+				return err
+			}
+
+			status, err := client.GetVersioning()
+			if err != nil {
+				return withExitCode(kindExitCode(obsclient.Classify(err)), fmt.Errorf("get versioning of %s failed: %w", name, err))
+			}
+			return formatter.Render([]output.VersioningItem{{Profile: name, Bucket: client.Bucket, Status: status}})
+		},
+	}
+	return cmd
+}
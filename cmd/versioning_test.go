@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersioningEnableJSON(t *testing.T) {
+	got := runAgainst(t, "obs", "versioning", "enable", "env", "-o", "json")
+	if !strings.Contains(got, `"Status": "Enabled"`) {
+		t.Errorf("expected the versioning state on stdout, got %q", got)
+	}
+}
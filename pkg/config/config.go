@@ -1,8 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+
+	"obsput/pkg/layout"
+	"obsput/pkg/obs"
+	"obsput/pkg/retention"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,12 +17,88 @@ type OBS struct {
 	Name     string `yaml:"name"`
 	Endpoint string `yaml:"endpoint"`
 	Bucket   string `yaml:"bucket"`
-	AK       string `yaml:"ak"`
-	SK       string `yaml:"sk"`
+	AK       string `yaml:"ak,omitempty"`
+	SK       string `yaml:"sk,omitempty"`
+	// AKEnv and SKEnv name environment variables holding the keys, which
+	// then need not be stored in the file
+	AKEnv string `yaml:"ak_env,omitempty"`
+	SKEnv string `yaml:"sk_env,omitempty"`
+	// SecurityToken goes with temporary AK/SK; SecurityTokenEnv names the
+	// variable holding it when the AK comes from ak_env
+	SecurityToken    string `yaml:"security_token,omitempty"`
+	SecurityTokenEnv string `yaml:"security_token_env,omitempty"`
+	// CredentialProcess is a command printing short-lived credentials as
+	// JSON; it replaces all other sources of AK and SK
+	CredentialProcess string `yaml:"credential_process,omitempty"`
+	// KeyTemplate is the object key layout, e.g.
+	// "{project}/{branch}/{version}/{os}-{arch}/{filename}"
+	KeyTemplate string `yaml:"key_template,omitempty"`
+	// Project fills the {project} placeholder
+	Project string `yaml:"project,omitempty"`
+	// Retention decides which versions `obsput prune` keeps
+	Retention *retention.Policy `yaml:"retention,omitempty"`
+	// Protect lists versions that delete and prune never remove
+	Protect *retention.Protection `yaml:"protect,omitempty"`
+	// SoftDelete makes delete and prune move versions to the trash
+	SoftDelete bool `yaml:"soft_delete,omitempty"`
+	// TLS sets a CA bundle, a client certificate or insecure_skip_verify
+	TLS *obs.TLSOptions `yaml:"tls,omitempty"`
+	// HTTP sets the proxy, timeouts and connection limits
+	HTTP *obs.HTTPOptions `yaml:"http,omitempty"`
+}
+
+// GetKeyTemplate returns the profile's key layout, or the default layout
+func (o *OBS) GetKeyTemplate() string {
+	if o.KeyTemplate == "" {
+		return layout.Default
+	}
+	return o.KeyTemplate
+}
+
+// Validate checks the profile settings that can be verified offline
+func (o *OBS) Validate() error {
+	if _, err := obs.ParseEndpoint(o.Endpoint); err != nil {
+		return fmt.Errorf("profile '%s': %v", o.Name, err)
+	}
+	if err := o.TLS.Validate(); err != nil {
+		return fmt.Errorf("profile '%s': invalid tls: %v", o.Name, err)
+	}
+	if err := o.HTTP.Validate(); err != nil {
+		return fmt.Errorf("profile '%s': invalid http: %v", o.Name, err)
+	}
+	if _, err := layout.Parse(o.GetKeyTemplate()); err != nil {
+		return fmt.Errorf("profile '%s': invalid key_template: %v", o.Name, err)
+	}
+	if o.Retention != nil {
+		if err := o.Retention.Validate(); err != nil {
+			return fmt.Errorf("profile '%s': invalid retention: %v", o.Name, err)
+		}
+	}
+	if o.Protect != nil {
+		if err := o.Protect.Validate(); err != nil {
+			return fmt.Errorf("profile '%s': invalid protect: %v", o.Name, err)
+		}
+	}
+	return nil
 }
 
 type Config struct {
 	Configs map[string]*OBS `yaml:"configs"`
+	// Encryption is set when the keys are stored encrypted (obs lock)
+	Encryption *Encryption `yaml:"encryption,omitempty"`
+	// fromEnv marks a config built by FromEnv, which has no file
+	fromEnv bool
+	// key decrypts and encrypts the keys of a locked config
+	key []byte
+}
+
+// useEnv makes LoadOrInit build the config from the environment
+var useEnv bool
+
+// UseEnv makes LoadOrInit return FromEnv instead of reading the config
+// file, for --profile-from-env
+func UseEnv(on bool) {
+	useEnv = on
 }
 
 func NewConfig() *Config {
@@ -64,6 +146,16 @@ func (c *Config) ListOBS() []*OBS {
 	return obsList
 }
 
+// names returns the profile names in order
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Configs))
+	for name := range c.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (c *Config) RemoveOBS(name string) {
 	delete(c.Configs, name)
 }
@@ -74,15 +166,27 @@ func (c *Config) OBSExists(name string) bool {
 }
 
 func (c *Config) Save(path string) error {
+	if c.fromEnv {
+		return fmt.Errorf("the profile comes from the environment (--profile-from-env) and cannot be saved")
+	}
 	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	// The config holds keys: only the owner may read it
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return err
 	}
-	data, err := yaml.Marshal(c)
+	stored, err := c.encrypted()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	data, err := yaml.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0600)
 }
 
 func (c *Config) Ensure(path string) error {
@@ -104,10 +208,21 @@ func Load(path string) (*Config, error) {
 	if cfg.Configs == nil {
 		cfg.Configs = make(map[string]*OBS)
 	}
+	if err := cfg.decrypt(); err != nil {
+		return nil, err
+	}
+	for _, obs := range cfg.Configs {
+		if err := obs.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	return &cfg, nil
 }
 
 func LoadOrInit() (*Config, error) {
+	if useEnv {
+		return FromEnv()
+	}
 	path, err := GetConfigPath()
 	if err != nil {
 		return nil, err
@@ -115,6 +230,9 @@ func LoadOrInit() (*Config, error) {
 
 	cfg, err := Load(path)
 	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
 		// Config doesn't exist, create new one
 		cfg = NewConfig()
 		// Auto-generate template
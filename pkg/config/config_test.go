@@ -1,8 +1,13 @@
 package config
 
 import (
+	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
+
+	"obsput/pkg/retention"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -90,6 +95,35 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestSaveRestrictsPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions on Windows")
+	}
+	dir := filepath.Join(t.TempDir(), "conf")
+	cfgPath := filepath.Join(dir, "obsput.yaml")
+
+	cfg := NewConfig()
+	cfg.AddOBS("test", "obs.test.com", "bucket", "ak", "sk")
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("config dir mode %v, want 0700", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(cfgPath); info.Mode().Perm() != 0600 {
+		t.Errorf("config mode %v, want 0600", info.Mode().Perm())
+	}
+
+	// A config written by an older version is tightened on the next save
+	os.Chmod(cfgPath, 0644)
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if info, _ := os.Stat(cfgPath); info.Mode().Perm() != 0600 {
+		t.Errorf("existing config mode %v, want 0600", info.Mode().Perm())
+	}
+}
+
 func TestOBSExists(t *testing.T) {
 	cfg := NewConfig()
 	cfg.AddOBS("exists", "obs.com", "bucket", "ak", "sk")
@@ -101,3 +135,180 @@ func TestOBSExists(t *testing.T) {
 		t.Error("Exists should return false for non-existing config")
 	}
 }
+
+func TestLoadRejectsInvalidKeyTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "test.yaml")
+
+	cfg := NewConfig()
+	cfg.AddOBS("test", "obs.test.com", "bucket", "ak", "sk")
+	cfg.Configs["test"].KeyTemplate = "{project}/{filename}"
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := Load(cfgPath); err == nil {
+		t.Error("Load should reject a key template without {version}")
+	}
+}
+
+func TestLoadRetention(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "test.yaml")
+
+	data := `configs:
+  prod:
+    name: prod
+    endpoint: obs.test.com
+    bucket: bucket
+    ak: ak
+    sk: sk
+    retention:
+      keep_last: 5
+      keep_tagged: true
+`
+	if err := os.WriteFile(cfgPath, []byte(data), 0644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	policy := cfg.GetOBS("prod").Retention
+	if policy == nil || policy.KeepLast != 5 || !policy.KeepTagged {
+		t.Errorf("unexpected retention: %+v", policy)
+	}
+
+	cfg.Configs["prod"].Retention = &retention.Policy{}
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Load(cfgPath); err == nil {
+		t.Error("Load should reject a retention policy without keep rules")
+	}
+}
+
+func TestLoadProtect(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "test.yaml")
+
+	data := `configs:
+  prod:
+    name: prod
+    endpoint: obs.test.com
+    bucket: bucket
+    ak: ak
+    sk: sk
+    protect:
+      versions: ["v1.0.0-*"]
+      tags: ["v*"]
+      prefixes: [releases/stable]
+`
+	if err := os.WriteFile(cfgPath, []byte(data), 0644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	protect := cfg.GetOBS("prod").Protect
+	if protect == nil || len(protect.Versions) != 1 || len(protect.Tags) != 1 || protect.Prefixes[0] != "releases/stable" {
+		t.Errorf("unexpected protect: %+v", protect)
+	}
+
+	cfg.Configs["prod"].Protect = &retention.Protection{Versions: []string{"v1.["}}
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Load(cfgPath); err == nil {
+		t.Error("Load should reject a malformed protect pattern")
+	}
+}
+
+func TestLoadValidatesEndpointAndTLS(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "test.yaml")
+	caPath := filepath.Join(tmpDir, "ca.pem")
+	if err := os.WriteFile(caPath, []byte("ca"), 0644); err != nil {
+		t.Fatalf("write ca failed: %v", err)
+	}
+
+	data := `configs:
+  prod:
+    name: prod
+    endpoint: https://obs.test.com:8443
+    bucket: bucket
+    ak: ak
+    sk: sk
+    tls:
+      ca_bundle: ` + caPath + `
+      insecure_skip_verify: true
+`
+	if err := os.WriteFile(cfgPath, []byte(data), 0644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	tls := cfg.GetOBS("prod").TLS
+	if tls == nil || tls.CABundle != caPath || !tls.InsecureSkipVerify {
+		t.Errorf("unexpected tls: %+v", tls)
+	}
+
+	cfg.Configs["prod"].TLS.ClientCert = caPath
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Load(cfgPath); err == nil {
+		t.Error("Load should reject a client_cert without client_key")
+	}
+
+	cfg.Configs["prod"].TLS = nil
+	cfg.Configs["prod"].Endpoint = "ftp://obs.test.com"
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Load(cfgPath); err == nil {
+		t.Error("Load should reject an endpoint that is not http or https")
+	}
+}
+
+func TestLoadHTTPOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "test.yaml")
+
+	data := `configs:
+  prod:
+    name: prod
+    endpoint: obs.test.com
+    bucket: bucket
+    ak: ak
+    sk: sk
+    http:
+      proxy: http://proxy.corp.example:3128
+      connect_timeout: 10s
+      timeout: 30m
+      max_idle_conns: 10
+`
+	if err := os.WriteFile(cfgPath, []byte(data), 0644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	opts := cfg.GetOBS("prod").HTTP
+	if opts == nil || opts.Proxy != "http://proxy.corp.example:3128" || opts.ConnectTimeout != 10*time.Second ||
+		opts.Timeout != 30*time.Minute || opts.MaxIdleConns != 10 {
+		t.Errorf("unexpected http options: %+v", opts)
+	}
+
+	cfg.Configs["prod"].HTTP.Proxy = "proxy.corp.example:3128"
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := Load(cfgPath); err == nil {
+		t.Error("Load should reject a proxy without a scheme")
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Generic credential variables, used by every profile without its own
+const (
+	EnvAccessKey     = "OBS_ACCESS_KEY_ID"
+	EnvSecretKey     = "OBS_SECRET_ACCESS_KEY"
+	EnvSecurityToken = "OBS_SECURITY_TOKEN"
+)
+
+// Variables read by --profile-from-env
+const (
+	EnvProfile           = "OBSPUT_PROFILE"
+	EnvEndpoint          = "OBSPUT_ENDPOINT"
+	EnvBucket            = "OBSPUT_BUCKET"
+	EnvKeyTemplate       = "OBSPUT_KEY_TEMPLATE"
+	EnvProject           = "OBSPUT_PROJECT"
+	EnvCredentialProcess = "OBSPUT_CREDENTIAL_PROCESS"
+
+	// DefaultEnvProfile names the profile when OBSPUT_PROFILE is not set
+	DefaultEnvProfile = "env"
+)
+
+// ProfileEnv returns the variable that overrides one credential of a
+// profile, e.g. OBSPUT_CN_EAST_AK for profile cn-east and suffix AK
+func ProfileEnv(profile, suffix string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, profile)
+	return "OBSPUT_" + name + "_" + suffix
+}
+
+// Credentials returns the access key and secret key of the profile. With a
+// credential_process both are empty: the client runs the process. Otherwise
+// each key is taken from the first of:
+//
+//  1. OBSPUT_<PROFILE>_AK / OBSPUT_<PROFILE>_SK
+//  2. the variables named by ak_env / sk_env
+//  3. OBS_ACCESS_KEY_ID / OBS_SECRET_ACCESS_KEY
+//  4. ak / sk in the config file
+func (o *OBS) Credentials() (ak, sk string, err error) {
+	if ak, _, err = o.AccessKey(); err != nil {
+		return "", "", err
+	}
+	if sk, _, err = o.SecretKey(); err != nil {
+		return "", "", err
+	}
+	return ak, sk, nil
+}
+
+// AccessKey returns the access key and where it comes from, e.g.
+// "env OBSPUT_PROD_AK" or "config"
+func (o *OBS) AccessKey() (value, source string, err error) {
+	return o.credential("AK", "ak", o.AKEnv, EnvAccessKey, o.AK)
+}
+
+// SecretKey returns the secret key and where it comes from
+func (o *OBS) SecretKey() (value, source string, err error) {
+	return o.credential("SK", "sk", o.SKEnv, EnvSecretKey, o.SK)
+}
+
+// Token returns the security token of temporary credentials and where it
+// comes from, or "" for permanent keys. The variable named by
+// security_token_env holds it whatever the AK source, unless the AK comes
+// from OBSPUT_<PROFILE>_AK; otherwise it comes from the same place as the
+// AK: OBSPUT_<PROFILE>_SECURITY_TOKEN, OBS_SECURITY_TOKEN or security_token.
+func (o *OBS) Token() (value, source string, err error) {
+	if o.CredentialProcess != "" {
+		return "", "", nil
+	}
+	_, akSource, err := o.AccessKey()
+	if err != nil {
+		return "", "", err
+	}
+	var name string
+	switch {
+	case akSource == "env "+ProfileEnv(o.Name, "AK"):
+		name = ProfileEnv(o.Name, "SECURITY_TOKEN")
+	case o.SecurityTokenEnv != "":
+		if v := os.Getenv(o.SecurityTokenEnv); v != "" {
+			return v, "env " + o.SecurityTokenEnv, nil
+		}
+		return "", "", fmt.Errorf("profile '%s': security_token_env names %s, which is not set", o.Name, o.SecurityTokenEnv)
+	case akSource == "env "+EnvAccessKey:
+		name = EnvSecurityToken
+	case akSource == "config" && o.SecurityToken != "":
+		return o.SecurityToken, "config", nil
+	}
+	if v := os.Getenv(name); name != "" && v != "" {
+		return v, "env " + name, nil
+	}
+	return "", "", nil
+}
+
+func (o *OBS) credential(suffix, field, indirect, generic, plain string) (value, source string, err error) {
+	if o.CredentialProcess != "" {
+		return "", "credential_process", nil
+	}
+	profileVar := ProfileEnv(o.Name, suffix)
+	if v := os.Getenv(profileVar); v != "" {
+		return v, "env " + profileVar, nil
+	}
+	if indirect != "" {
+		if v := os.Getenv(indirect); v != "" {
+			return v, "env " + indirect, nil
+		}
+		return "", "", fmt.Errorf("profile '%s': %s_env names %s, which is not set", o.Name, field, indirect)
+	}
+	if v := os.Getenv(generic); v != "" {
+		return v, "env " + generic, nil
+	}
+	if plain != "" {
+		return plain, "config", nil
+	}
+	return "", "", fmt.Errorf("profile '%s': no %s; set %s or %s_env in the profile, or %s or %s",
+		o.Name, field, field, field, profileVar, generic)
+}
+
+// FromEnv builds a config with a single profile from OBSPUT_ENDPOINT,
+// OBSPUT_BUCKET and optionally OBSPUT_PROFILE, OBSPUT_KEY_TEMPLATE,
+// OBSPUT_PROJECT and OBSPUT_CREDENTIAL_PROCESS. Its credentials come from the environment as usual.
+func FromEnv() (*Config, error) {
+	name := os.Getenv(EnvProfile)
+	if name == "" {
+		name = DefaultEnvProfile
+	}
+	obs := &OBS{
+		Name:              name,
+		Endpoint:          os.Getenv(EnvEndpoint),
+		Bucket:            os.Getenv(EnvBucket),
+		KeyTemplate:       os.Getenv(EnvKeyTemplate),
+		Project:           os.Getenv(EnvProject),
+		CredentialProcess: os.Getenv(EnvCredentialProcess),
+	}
+	if obs.Endpoint == "" {
+		return nil, fmt.Errorf("%s is not set", EnvEndpoint)
+	}
+	if obs.Bucket == "" {
+		return nil, fmt.Errorf("%s is not set", EnvBucket)
+	}
+	if err := obs.Validate(); err != nil {
+		return nil, err
+	}
+	if _, _, err := obs.Credentials(); err != nil {
+		return nil, err
+	}
+	cfg := NewConfig()
+	cfg.Configs[name] = obs
+	cfg.fromEnv = true
+	return cfg, nil
+}
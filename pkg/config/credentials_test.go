@@ -0,0 +1,162 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfileEnv(t *testing.T) {
+	tests := map[string]string{
+		"prod":      "OBSPUT_PROD_AK",
+		"cn-east":   "OBSPUT_CN_EAST_AK",
+		"Mirror.v2": "OBSPUT_MIRROR_V2_AK",
+	}
+	for profile, want := range tests {
+		if got := ProfileEnv(profile, "AK"); got != want {
+			t.Errorf("ProfileEnv(%q) = %q, want %q", profile, got, want)
+		}
+	}
+}
+
+func TestCredentialsPrecedence(t *testing.T) {
+	for _, name := range []string{"OBSPUT_PROD_AK", "OBSPUT_PROD_SK", "CI_AK", "CI_SK", EnvAccessKey, EnvSecretKey} {
+		t.Setenv(name, "")
+	}
+	obs := &OBS{Name: "prod", AK: "file-ak", SK: "file-sk"}
+
+	check := func(wantAK, wantSK, wantSource string) {
+		t.Helper()
+		ak, sk, err := obs.Credentials()
+		if err != nil {
+			t.Fatalf("Credentials failed: %v", err)
+		}
+		if ak != wantAK || sk != wantSK {
+			t.Errorf("Credentials = %q, %q, want %q, %q", ak, sk, wantAK, wantSK)
+		}
+		if _, source, _ := obs.AccessKey(); source != wantSource {
+			t.Errorf("AK source = %q, want %q", source, wantSource)
+		}
+	}
+
+	check("file-ak", "file-sk", "config")
+
+	t.Setenv(EnvAccessKey, "generic-ak")
+	t.Setenv(EnvSecretKey, "generic-sk")
+	check("generic-ak", "generic-sk", "env "+EnvAccessKey)
+
+	obs.AKEnv, obs.SKEnv = "CI_AK", "CI_SK"
+	t.Setenv("CI_AK", "indirect-ak")
+	t.Setenv("CI_SK", "indirect-sk")
+	check("indirect-ak", "indirect-sk", "env CI_AK")
+
+	t.Setenv("OBSPUT_PROD_AK", "profile-ak")
+	check("profile-ak", "indirect-sk", "env OBSPUT_PROD_AK")
+
+	// The process replaces every other source
+	obs.CredentialProcess = "vault-creds obs"
+	check("", "", "credential_process")
+}
+
+func TestCredentialsMissing(t *testing.T) {
+	for _, name := range []string{"OBSPUT_PROD_AK", "OBSPUT_PROD_SK", "CI_SK", EnvAccessKey, EnvSecretKey} {
+		t.Setenv(name, "")
+	}
+
+	obs := &OBS{Name: "prod", AK: "ak", SKEnv: "CI_SK"}
+	if _, _, err := obs.Credentials(); err == nil || !strings.Contains(err.Error(), "CI_SK") {
+		t.Errorf("expected an error naming the unset sk_env variable, got %v", err)
+	}
+
+	obs = &OBS{Name: "prod", AK: "ak"}
+	_, _, err := obs.Credentials()
+	if err == nil || !strings.Contains(err.Error(), "OBSPUT_PROD_SK") {
+		t.Errorf("expected an error listing the variables to set, got %v", err)
+	}
+	if _, source, _ := obs.SecretKey(); source != "" {
+		t.Errorf("missing SK should have no source, got %q", source)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	for _, name := range []string{EnvProfile, EnvEndpoint, EnvBucket, EnvKeyTemplate, EnvProject, "OBSPUT_CI_AK", "OBSPUT_CI_SK"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv(EnvAccessKey, "ak")
+	t.Setenv(EnvSecretKey, "sk")
+
+	if _, err := FromEnv(); err == nil || !strings.Contains(err.Error(), EnvEndpoint) {
+		t.Errorf("expected %s to be required, got %v", EnvEndpoint, err)
+	}
+
+	t.Setenv(EnvEndpoint, "obs.test.com")
+	t.Setenv(EnvBucket, "bucket")
+	t.Setenv(EnvProfile, "ci")
+	t.Setenv(EnvProject, "tool")
+	cfg, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv failed: %v", err)
+	}
+	obs := cfg.GetOBS("ci")
+	if obs == nil || obs.Endpoint != "obs.test.com" || obs.Bucket != "bucket" || obs.Project != "tool" {
+		t.Fatalf("unexpected profiles %+v", cfg.Configs)
+	}
+	if err := cfg.Save(t.TempDir() + "/obsput.yaml"); err == nil {
+		t.Error("a config from the environment should not be saved")
+	}
+
+	t.Setenv(EnvSecretKey, "")
+	if _, err := FromEnv(); err == nil {
+		t.Error("FromEnv should fail without a secret key")
+	}
+}
+
+func TestToken(t *testing.T) {
+	for _, name := range []string{"OBSPUT_PROD_AK", "OBSPUT_PROD_SECURITY_TOKEN", "CI_AK", "CI_TOKEN", EnvAccessKey, EnvSecurityToken} {
+		t.Setenv(name, "")
+	}
+	obs := &OBS{Name: "prod", AK: "ak", SK: "sk", SecurityToken: "file-token"}
+	if token, source, err := obs.Token(); token != "file-token" || source != "config" || err != nil {
+		t.Errorf("Token() = %q, %q, %v, want the token from the config", token, source, err)
+	}
+
+	// The token comes from the same place as the AK
+	t.Setenv(EnvAccessKey, "generic-ak")
+	if token, _, _ := obs.Token(); token != "" {
+		t.Errorf("a generic AK without OBS_SECURITY_TOKEN should have no token, got %q", token)
+	}
+	t.Setenv(EnvSecurityToken, "generic-token")
+	if token, source, _ := obs.Token(); token != "generic-token" || source != "env "+EnvSecurityToken {
+		t.Errorf("Token() = %q, %q, want the generic token", token, source)
+	}
+
+	obs.AKEnv, obs.SecurityTokenEnv = "CI_AK", "CI_TOKEN"
+	t.Setenv("CI_AK", "ci-ak")
+	t.Setenv("CI_TOKEN", "ci-token")
+	if token, _, _ := obs.Token(); token != "ci-token" {
+		t.Errorf("Token() = %q, want ci-token", token)
+	}
+
+	// security_token_env also applies to an AK from the config file
+	obs.AKEnv = ""
+	t.Setenv(EnvAccessKey, "")
+	if token, source, _ := obs.Token(); token != "ci-token" || source != "env CI_TOKEN" {
+		t.Errorf("Token() = %q, %q, want ci-token for an AK from the config", token, source)
+	}
+
+	// A variable that is named but not set is an error, not a missing token
+	t.Setenv("CI_TOKEN", "")
+	if _, _, err := obs.Token(); err == nil || !strings.Contains(err.Error(), "security_token_env names CI_TOKEN") {
+		t.Errorf("expected an error for the unset CI_TOKEN, got %v", err)
+	}
+
+	t.Setenv("OBSPUT_PROD_AK", "profile-ak")
+	t.Setenv("OBSPUT_PROD_SECURITY_TOKEN", "profile-token")
+	if token, _, _ := obs.Token(); token != "profile-token" {
+		t.Errorf("Token() = %q, want profile-token", token)
+	}
+
+	obs.CredentialProcess = "vault-creds"
+	if token, source, err := obs.Token(); token != "" || source != "" || err != nil {
+		t.Errorf("a credential process supplies its own token, got %q from %q (%v)", token, source, err)
+	}
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Variables supplying the passphrase of an encrypted config
+const (
+	EnvPassphrase     = "OBSPUT_PASSPHRASE"
+	EnvPassphraseFile = "OBSPUT_PASSPHRASE_FILE"
+)
+
+const (
+	// KDFPBKDF2 derives the key with PBKDF2-HMAC-SHA256
+	KDFPBKDF2 = "pbkdf2-sha256"
+	// DefaultIterations is the PBKDF2 work factor of newly locked configs
+	DefaultIterations = 600000
+
+	// encryptedPrefix marks an encrypted value: the prefix followed by the
+	// base64 of the nonce and the AES-256-GCM ciphertext
+	encryptedPrefix = "enc:v1:"
+	// checkPlaintext is encrypted into Encryption.Check to tell a wrong
+	// passphrase from a damaged value
+	checkPlaintext = "obsput"
+	saltSize       = 16
+)
+
+// ErrNoPassphrase is returned when the config is encrypted and no
+// passphrase is available
+var ErrNoPassphrase = errors.New("the config is encrypted: set " + EnvPassphrase + " or " + EnvPassphraseFile + ", pass --passphrase-file, or run in a terminal to be asked")
+
+// Encryption describes how ak, sk and security_token are encrypted:
+//
+//	encryption:
+//	  kdf: pbkdf2-sha256
+//	  iterations: 600000
+//	  salt: 3q2+7w...
+//	  check: enc:v1:...
+type Encryption struct {
+	KDF        string `yaml:"kdf"`
+	Iterations int    `yaml:"iterations"`
+	// Salt is base64
+	Salt string `yaml:"salt"`
+	// Check is a known value encrypted with the key, to detect a wrong
+	// passphrase
+	Check string `yaml:"check"`
+}
+
+// iterations is the work factor Lock uses; tests lower it
+var iterations = DefaultIterations
+
+// passphraseFile is set by UsePassphraseFile, for --passphrase-file
+var passphraseFile string
+
+// UsePassphraseFile makes Load read the passphrase from path, before the
+// environment
+func UsePassphraseFile(path string) {
+	passphraseFile = path
+}
+
+// promptPassphrase asks for the passphrase; nil when not interactive
+var promptPassphrase func(question string) (string, error)
+
+// SetPrompt sets the function asking the user for the passphrase when no
+// file or variable provides one
+func SetPrompt(prompt func(question string) (string, error)) {
+	promptPassphrase = prompt
+}
+
+// Passphrase returns the passphrase from --passphrase-file,
+// OBSPUT_PASSPHRASE, OBSPUT_PASSPHRASE_FILE or the prompt, in that order
+func Passphrase() (string, error) {
+	return passphrase("Config passphrase: ")
+}
+
+func passphrase(question string) (string, error) {
+	path := passphraseFile
+	if path == "" {
+		if v := os.Getenv(EnvPassphrase); v != "" {
+			return v, nil
+		}
+		path = os.Getenv(EnvPassphraseFile)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read passphrase file: %v", err)
+		}
+		// Only the trailing newline is dropped: the passphrase may end in spaces
+		v := strings.TrimRight(string(data), "\r\n")
+		if v == "" {
+			return "", fmt.Errorf("passphrase file %s is empty", path)
+		}
+		return v, nil
+	}
+	if promptPassphrase == nil {
+		return "", ErrNoPassphrase
+	}
+	v, err := promptPassphrase(question)
+	if err != nil {
+		return "", err
+	}
+	if v == "" {
+		return "", fmt.Errorf("empty passphrase")
+	}
+	return v, nil
+}
+
+// NewPassphrase returns the passphrase for locking a config. When asked
+// for, it must be entered twice.
+func NewPassphrase() (string, error) {
+	if passphraseFile != "" || os.Getenv(EnvPassphrase) != "" || os.Getenv(EnvPassphraseFile) != "" || promptPassphrase == nil {
+		return Passphrase()
+	}
+	first, err := passphrase("New config passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	second, err := passphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if first != second {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return first, nil
+}
+
+// IsEncrypted reports whether value was encrypted by Lock
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Locked reports whether the config keeps its keys encrypted
+func (c *Config) Locked() bool {
+	return c.Encryption != nil
+}
+
+// Lock encrypts the keys of every profile with passphrase from the next
+// Save on; profiles added later are encrypted too
+func (c *Config) Lock(passphrase string) error {
+	if c.Locked() {
+		return fmt.Errorf("the config is already locked")
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	enc := &Encryption{
+		KDF:        KDFPBKDF2,
+		Iterations: iterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+	}
+	key, err := enc.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+	if enc.Check, err = encryptValue(key, checkPlaintext); err != nil {
+		return err
+	}
+	c.Encryption, c.key = enc, key
+	return nil
+}
+
+// Unlock makes the next Save store the keys in plain text again
+func (c *Config) Unlock() error {
+	if !c.Locked() {
+		return fmt.Errorf("the config is not locked")
+	}
+	c.Encryption, c.key = nil, nil
+	return nil
+}
+
+// decrypt replaces the encrypted keys of every profile with plain text. The
+// passphrase is only asked for when a profile has an encrypted key.
+func (c *Config) decrypt() error {
+	var encrypted []secret
+	for _, name := range c.names() {
+		obs := c.Configs[name]
+		for _, field := range obs.secrets() {
+			if IsEncrypted(*field.value) {
+				field.name = fmt.Sprintf("profile '%s': %s", obs.Name, field.name)
+				encrypted = append(encrypted, field)
+			}
+		}
+	}
+	if len(encrypted) == 0 {
+		return nil
+	}
+	if c.Encryption == nil {
+		return fmt.Errorf("%s is encrypted but the config has no encryption section", encrypted[0].name)
+	}
+	if err := c.deriveKey(); err != nil {
+		return err
+	}
+	for _, field := range encrypted {
+		plain, err := decryptValue(c.key, *field.value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", field.name, err)
+		}
+		*field.value = plain
+	}
+	return nil
+}
+
+// deriveKey asks for the passphrase and derives the key, checking it
+// against Encryption.Check
+func (c *Config) deriveKey() error {
+	pass, err := Passphrase()
+	if err != nil {
+		return err
+	}
+	key, err := c.Encryption.deriveKey(pass)
+	if err != nil {
+		return err
+	}
+	if check, err := decryptValue(key, c.Encryption.Check); err != nil || check != checkPlaintext {
+		return fmt.Errorf("wrong passphrase")
+	}
+	c.key = key
+	return nil
+}
+
+// encrypted returns a copy of the config to write to the file, with the
+// keys encrypted when the config is locked
+func (c *Config) encrypted() (*Config, error) {
+	if c.Encryption == nil {
+		return c, nil
+	}
+	out := &Config{Configs: make(map[string]*OBS, len(c.Configs)), Encryption: c.Encryption}
+	for name, obs := range c.Configs {
+		copied := *obs
+		for _, field := range copied.secrets() {
+			if *field.value == "" || IsEncrypted(*field.value) {
+				continue
+			}
+			if c.key == nil {
+				if err := c.deriveKey(); err != nil {
+					return nil, err
+				}
+			}
+			v, err := encryptValue(c.key, *field.value)
+			if err != nil {
+				return nil, err
+			}
+			*field.value = v
+		}
+		out.Configs[name] = &copied
+	}
+	return out, nil
+}
+
+// secret is a profile field that Lock encrypts
+type secret struct {
+	name  string
+	value *string
+}
+
+func (o *OBS) secrets() []secret {
+	return []secret{
+		{"ak", &o.AK},
+		{"sk", &o.SK},
+		{"security_token", &o.SecurityToken},
+	}
+}
+
+func (e *Encryption) deriveKey(passphrase string) ([]byte, error) {
+	if e.KDF != KDFPBKDF2 {
+		return nil, fmt.Errorf("unsupported encryption kdf %q", e.KDF)
+	}
+	if e.Iterations <= 0 {
+		return nil, fmt.Errorf("invalid encryption iterations %d", e.Iterations)
+	}
+	salt, err := base64.StdEncoding.DecodeString(e.Salt)
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid encryption salt")
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, e.Iterations, 32)
+}
+
+func encryptValue(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptValue(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("not an encrypted value")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %v", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value: too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: authentication failed")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
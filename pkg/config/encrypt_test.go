@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// usePassphrase sets OBSPUT_PASSPHRASE and clears the other sources
+func usePassphrase(t *testing.T, passphrase string) {
+	t.Helper()
+	t.Setenv(EnvPassphrase, passphrase)
+	t.Setenv(EnvPassphraseFile, "")
+	UsePassphraseFile("")
+	SetPrompt(nil)
+}
+
+// fewIterations makes Lock derive keys quickly
+func fewIterations(t *testing.T) {
+	t.Helper()
+	saved := iterations
+	iterations = 1000
+	t.Cleanup(func() { iterations = saved })
+}
+
+func TestLockAndLoad(t *testing.T) {
+	fewIterations(t)
+	usePassphrase(t, "correct horse")
+	path := filepath.Join(t.TempDir(), "obsput.yaml")
+
+	cfg := NewConfig()
+	cfg.AddOBS("prod", "obs.test.com", "bucket", "AKPLAIN", "sk-plain")
+	cfg.GetOBS("prod").SecurityToken = "token-plain"
+	if err := cfg.Lock("correct horse"); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, plain := range []string{"AKPLAIN", "sk-plain", "token-plain"} {
+		if strings.Contains(string(data), plain) {
+			t.Errorf("%s stored in plain text:\n%s", plain, data)
+		}
+	}
+	// Saving leaves the config in memory decrypted
+	if cfg.GetOBS("prod").SK != "sk-plain" {
+		t.Errorf("Save changed the in-memory key to %q", cfg.GetOBS("prod").SK)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	obs := loaded.GetOBS("prod")
+	if obs.AK != "AKPLAIN" || obs.SK != "sk-plain" || obs.SecurityToken != "token-plain" {
+		t.Errorf("decrypted %q %q %q", obs.AK, obs.SK, obs.SecurityToken)
+	}
+
+	// Profiles added to a locked config are encrypted as well
+	loaded.AddOBS("test", "obs.test.com", "bucket", "AKSECOND", "sk-second")
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if strings.Contains(string(data), "sk-second") {
+		t.Errorf("new profile stored in plain text:\n%s", data)
+	}
+
+	loaded, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := loaded.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "sk-second") || strings.Contains(string(data), "encryption") {
+		t.Errorf("unlocked config not in plain text:\n%s", data)
+	}
+}
+
+func TestLoadEncryptedPassphrase(t *testing.T) {
+	fewIterations(t)
+	usePassphrase(t, "secret")
+	path := filepath.Join(t.TempDir(), "obsput.yaml")
+	cfg := NewConfig()
+	cfg.AddOBS("prod", "obs.test.com", "bucket", "ak", "sk")
+	if err := cfg.Lock("secret"); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	t.Setenv(EnvPassphrase, "wrong")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("expected wrong passphrase, got %v", err)
+	}
+
+	t.Setenv(EnvPassphrase, "")
+	if _, err := Load(path); !errors.Is(err, ErrNoPassphrase) {
+		t.Errorf("expected ErrNoPassphrase, got %v", err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(keyFile, []byte("secret\n"), 0600)
+	t.Setenv(EnvPassphraseFile, keyFile)
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load with OBSPUT_PASSPHRASE_FILE failed: %v", err)
+	}
+
+	t.Setenv(EnvPassphraseFile, "")
+	asked := 0
+	SetPrompt(func(string) (string, error) {
+		asked++
+		return "secret", nil
+	})
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load with the prompt failed: %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("asked %d times, want once", asked)
+	}
+}
+
+func TestLoadRejectsTamperedValue(t *testing.T) {
+	fewIterations(t)
+	usePassphrase(t, "secret")
+	path := filepath.Join(t.TempDir(), "obsput.yaml")
+	cfg := NewConfig()
+	cfg.AddOBS("prod", "obs.test.com", "bucket", "ak", "sk")
+	cfg.Lock("secret")
+	cfg.Save(path)
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "sk: "+encryptedPrefix) {
+			lines[i] = line[:len(line)-4] + "AAAA"
+		}
+	}
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "profile 'prod': sk") {
+		t.Errorf("expected a decryption error for sk, got %v", err)
+	}
+}
+
+func TestLockTwice(t *testing.T) {
+	fewIterations(t)
+	cfg := NewConfig()
+	if err := cfg.Unlock(); err == nil {
+		t.Error("Unlock of a plain config should fail")
+	}
+	cfg.Lock("secret")
+	if err := cfg.Lock("other"); err == nil {
+		t.Error("Lock of a locked config should fail")
+	}
+}
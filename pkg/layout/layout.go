@@ -0,0 +1,346 @@
+package layout
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Default reproduces the original prefix/version/filename key layout
+const Default = "{prefix}/{version}/{filename}"
+
+// Placeholders lists the names a key template may use
+var Placeholders = []string{"prefix", "project", "branch", "version", "os", "arch", "filename"}
+
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+type token struct {
+	literal string
+	name    string
+}
+
+type segment struct {
+	tokens  []token
+	pattern *regexp.Regexp
+}
+
+// placeholder returns the name if the segment is exactly one placeholder
+func (s segment) placeholder() string {
+	if len(s.tokens) == 1 {
+		return s.tokens[0].name
+	}
+	return ""
+}
+
+// Template is a parsed object key layout such as
+// "{project}/{branch}/{version}/{os}-{arch}/{filename}"
+type Template struct {
+	raw      string
+	segments []segment
+}
+
+// Parse validates and parses a key template.
+// {version} and {filename} are required and must each fill a whole path
+// segment, {filename} must be last, and placeholders inside one segment must
+// be separated by literal text so keys can be matched back unambiguously.
+func Parse(s string) (*Template, error) {
+	if s == "" {
+		return nil, fmt.Errorf("key template is empty")
+	}
+	if strings.HasPrefix(s, "/") || strings.HasSuffix(s, "/") {
+		return nil, fmt.Errorf("key template %q must not start or end with '/'", s)
+	}
+
+	t := &Template{raw: s}
+	seen := make(map[string]bool)
+	parts := strings.Split(s, "/")
+	for i, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("key template %q contains an empty path segment", s)
+		}
+		seg, err := parseSegment(part)
+		if err != nil {
+			return nil, fmt.Errorf("key template %q: %v", s, err)
+		}
+		for _, tok := range seg.tokens {
+			if tok.name == "" {
+				continue
+			}
+			if seen[tok.name] {
+				return nil, fmt.Errorf("key template %q uses {%s} more than once", s, tok.name)
+			}
+			seen[tok.name] = true
+			switch tok.name {
+			case "version", "prefix":
+				if seg.placeholder() != tok.name {
+					return nil, fmt.Errorf("key template %q: {%s} must be a whole path segment", s, tok.name)
+				}
+			case "filename":
+				if seg.placeholder() != tok.name || i != len(parts)-1 {
+					return nil, fmt.Errorf("key template %q: {filename} must be the last path segment", s)
+				}
+			}
+		}
+		t.segments = append(t.segments, seg)
+	}
+
+	if !seen["version"] {
+		return nil, fmt.Errorf("key template %q must contain {version}", s)
+	}
+	if !seen["filename"] {
+		return nil, fmt.Errorf("key template %q must end with {filename}", s)
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics on error
+func MustParse(s string) *Template {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseSegment(part string) (segment, error) {
+	var seg segment
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(part, -1) {
+		if loc[0] > last {
+			literal := part[last:loc[0]]
+			seg.tokens = append(seg.tokens, token{literal: literal})
+			pattern.WriteString(regexp.QuoteMeta(literal))
+		} else if len(seg.tokens) > 0 {
+			return seg, fmt.Errorf("placeholders in %q must be separated by literal text", part)
+		}
+		name := part[loc[2]:loc[3]]
+		if !isPlaceholder(name) {
+			return seg, fmt.Errorf("unknown placeholder {%s} (supported: %s)", name, strings.Join(Placeholders, ", "))
+		}
+		seg.tokens = append(seg.tokens, token{name: name})
+		pattern.WriteString("(.+?)")
+		last = loc[1]
+	}
+	if last < len(part) {
+		literal := part[last:]
+		if strings.ContainsAny(literal, "{}") {
+			return seg, fmt.Errorf("unbalanced brace in %q", part)
+		}
+		seg.tokens = append(seg.tokens, token{literal: literal})
+		pattern.WriteString(regexp.QuoteMeta(literal))
+	}
+	pattern.WriteString("$")
+	seg.pattern = regexp.MustCompile(pattern.String())
+	return seg, nil
+}
+
+func isPlaceholder(name string) bool {
+	for _, p := range Placeholders {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the template source
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Uses reports whether the template contains the placeholder
+func (t *Template) Uses(name string) bool {
+	for _, seg := range t.segments {
+		for _, tok := range seg.tokens {
+			if tok.name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Render builds an object key from values.
+// A segment made of a single optional placeholder (anything but {version}
+// and {filename}) is dropped when its value is empty; any other missing value
+// is an error.
+func (t *Template) Render(values map[string]string) (string, error) {
+	parts := make([]string, 0, len(t.segments))
+	for _, seg := range t.segments {
+		if name := seg.placeholder(); name != "" && !isRequired(name) && cleanValue(name, values[name]) == "" {
+			continue
+		}
+		var b strings.Builder
+		for _, tok := range seg.tokens {
+			if tok.name == "" {
+				b.WriteString(tok.literal)
+				continue
+			}
+			value := cleanValue(tok.name, values[tok.name])
+			if value == "" {
+				return "", fmt.Errorf("key template %q needs a value for {%s}", t.raw, tok.name)
+			}
+			b.WriteString(value)
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// matchMode selects how much of the template a key must cover
+type matchMode int
+
+const (
+	// modeFull requires the whole template, down to {filename}
+	modeFull matchMode = iota
+	// modeVersion requires the key to reach at least {version}
+	modeVersion
+	// modeDir requires the key to stop above {version}
+	modeDir
+)
+
+// Match extracts placeholder values from a full object key
+func (t *Template) Match(key string) (map[string]string, bool) {
+	return t.match(key, modeFull)
+}
+
+// MatchVersion extracts placeholder values from a key or a key prefix that
+// reaches at least the {version} segment, e.g. "releases/v1.0.0-abc/"
+func (t *Template) MatchVersion(key string) (map[string]string, bool) {
+	return t.match(key, modeVersion)
+}
+
+// MatchVersionDir matches a "directory" whose last segment is the
+// {version} segment, as returned by a delimiter listing
+func (t *Template) MatchVersionDir(dir string) (map[string]string, bool) {
+	values, ok := t.match(dir, modeVersion)
+	if !ok {
+		return nil, false
+	}
+	parts := strings.Split(strings.TrimSuffix(dir, "/"), "/")
+	if parts[len(parts)-1] != values["version"] {
+		return nil, false
+	}
+	return values, true
+}
+
+// CanContainVersion reports whether versions may be found below dir, i.e.
+// dir matches the segments above {version}
+func (t *Template) CanContainVersion(dir string) bool {
+	_, ok := t.match(dir, modeDir)
+	return ok
+}
+
+func (t *Template) match(key string, mode matchMode) (map[string]string, bool) {
+	key = strings.TrimSuffix(key, "/")
+	if key == "" {
+		return nil, false
+	}
+	values := make(map[string]string)
+	if t.matchFrom(0, strings.Split(key, "/"), values, mode) {
+		return values, true
+	}
+	return nil, false
+}
+
+func (t *Template) matchFrom(ti int, parts []string, values map[string]string, mode matchMode) bool {
+	if ti == len(t.segments) {
+		return len(parts) == 0
+	}
+	if len(parts) == 0 {
+		switch mode {
+		case modeVersion:
+			return values["version"] != ""
+		case modeDir:
+			return values["version"] == ""
+		}
+		return false
+	}
+
+	seg := t.segments[ti]
+	name := seg.placeholder()
+
+	// Optional single-placeholder segments may be absent from the key
+	if name != "" && !isRequired(name) && t.matchFrom(ti+1, parts, values, mode) {
+		return true
+	}
+
+	// {prefix} may span several path segments
+	if name == "prefix" {
+		for n := 1; n <= len(parts); n++ {
+			values["prefix"] = strings.Join(parts[:n], "/")
+			if t.matchFrom(ti+1, parts[n:], values, mode) {
+				return true
+			}
+		}
+		delete(values, "prefix")
+		return false
+	}
+
+	groups := seg.pattern.FindStringSubmatch(parts[0])
+	if groups == nil {
+		return false
+	}
+	var names []string
+	for _, tok := range seg.tokens {
+		if tok.name != "" {
+			names = append(names, tok.name)
+		}
+	}
+	for i, n := range names {
+		if n == "version" && !LooksLikeVersion(groups[i+1]) {
+			return false
+		}
+		values[n] = groups[i+1]
+	}
+	if t.matchFrom(ti+1, parts[1:], values, mode) {
+		return true
+	}
+	for _, n := range names {
+		delete(values, n)
+	}
+	return false
+}
+
+// ListPrefix returns the longest literal key prefix that every key with the
+// given values shares, suitable as a listing prefix
+func (t *Template) ListPrefix(values map[string]string) string {
+	var b strings.Builder
+	for _, seg := range t.segments {
+		for _, tok := range seg.tokens {
+			if tok.name == "" {
+				b.WriteString(tok.literal)
+				continue
+			}
+			value := cleanValue(tok.name, values[tok.name])
+			if value == "" {
+				return b.String()
+			}
+			b.WriteString(value)
+		}
+		b.WriteString("/")
+	}
+	return b.String()
+}
+
+// LooksLikeVersion reports whether a path segment is a generated version,
+// e.g. v1.0.0-abc123-20260212-143000-1
+func LooksLikeVersion(s string) bool {
+	return strings.HasPrefix(s, "v") && strings.Contains(s, "-")
+}
+
+func isRequired(name string) bool {
+	return name == "version" || name == "filename"
+}
+
+// cleanValue keeps values from introducing extra path segments.
+// Only {prefix} may contain '/'.
+func cleanValue(name, value string) string {
+	if name == "prefix" {
+		return strings.Trim(value, "/")
+	}
+	return strings.ReplaceAll(value, "/", "-")
+}
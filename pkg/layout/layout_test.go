@@ -0,0 +1,123 @@
+package layout
+
+import "testing"
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"/{version}/{filename}",
+		"{version}",
+		"{filename}",
+		"{filename}/{version}",
+		"{project}//{version}/{filename}",
+		"{unknown}/{version}/{filename}",
+		"{os}{arch}/{version}/{filename}",
+		"{version}-x/{filename}",
+		"{version}/{version}/{filename}",
+		"{version}/{filename",
+	}
+	for _, s := range invalid {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("expected error for template %q", s)
+		}
+	}
+}
+
+func TestRenderDefault(t *testing.T) {
+	tmpl := MustParse(Default)
+
+	key, err := tmpl.Render(map[string]string{"prefix": "releases", "version": "v1.0.0-abc-20260212-143000-1", "filename": "app"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if key != "releases/v1.0.0-abc-20260212-143000-1/app" {
+		t.Errorf("unexpected key %s", key)
+	}
+
+	key, _ = tmpl.Render(map[string]string{"version": "v1.0.0-abc", "filename": "app"})
+	if key != "v1.0.0-abc/app" {
+		t.Errorf("empty prefix should be dropped, got %s", key)
+	}
+}
+
+func TestRenderCustom(t *testing.T) {
+	tmpl := MustParse("{project}/{branch}/{version}/{os}-{arch}/{filename}")
+	key, err := tmpl.Render(map[string]string{
+		"project": "obsput", "branch": "feature/x", "version": "v1.0.0-abc",
+		"os": "linux", "arch": "amd64", "filename": "obsput",
+	})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if key != "obsput/feature-x/v1.0.0-abc/linux-amd64/obsput" {
+		t.Errorf("unexpected key %s", key)
+	}
+
+	if _, err := tmpl.Render(map[string]string{"version": "v1.0.0-abc", "filename": "app"}); err == nil {
+		t.Error("expected error for missing {os}/{arch}")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tmpl := MustParse("{project}/{branch}/{version}/{os}-{arch}/{filename}")
+	values, ok := tmpl.Match("obsput/main/v1.0.0-abc/linux-arm64/obsput")
+	if !ok {
+		t.Fatal("expected key to match")
+	}
+	if values["version"] != "v1.0.0-abc" || values["os"] != "linux" || values["arch"] != "arm64" || values["branch"] != "main" {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	if _, ok := tmpl.Match(".obsput/build-number"); ok {
+		t.Error("counter object should not match")
+	}
+}
+
+func TestMatchDefaultPrefix(t *testing.T) {
+	tmpl := MustParse(Default)
+
+	values, ok := tmpl.Match("a/b/v1.0.0-abc/app")
+	if !ok || values["prefix"] != "a/b" || values["filename"] != "app" {
+		t.Errorf("unexpected match %v %v", values, ok)
+	}
+
+	values, ok = tmpl.MatchVersion("releases/v1.0.0-abc/")
+	if !ok || values["version"] != "v1.0.0-abc" || values["prefix"] != "releases" {
+		t.Errorf("unexpected partial match %v %v", values, ok)
+	}
+
+	if _, ok := tmpl.Match("releases/app"); ok {
+		t.Error("key without a version should not match")
+	}
+}
+
+func TestListPrefix(t *testing.T) {
+	tmpl := MustParse("builds/{version}/{filename}")
+	if p := tmpl.ListPrefix(map[string]string{"version": "v1-a"}); p != "builds/v1-a/" {
+		t.Errorf("unexpected list prefix %s", p)
+	}
+	if p := MustParse(Default).ListPrefix(map[string]string{"version": "v1-a"}); p != "" {
+		t.Errorf("unexpected list prefix %s", p)
+	}
+}
+
+func TestVersionDirs(t *testing.T) {
+	tmpl := MustParse(Default)
+	if values, ok := tmpl.MatchVersionDir("releases/v1.0.0-abc/"); !ok || values["prefix"] != "releases" {
+		t.Errorf("expected version dir match, got %v %v", values, ok)
+	}
+	if _, ok := tmpl.MatchVersionDir("releases/"); ok {
+		t.Error("plain prefix is not a version dir")
+	}
+	if !tmpl.CanContainVersion("releases/nightly/") {
+		t.Error("prefix dirs may contain versions")
+	}
+
+	custom := MustParse("{project}/{branch}/{version}/{os}-{arch}/{filename}")
+	if !custom.CanContainVersion("app/") || !custom.CanContainVersion("app/main/") {
+		t.Error("project and branch dirs may contain versions")
+	}
+	if _, ok := custom.MatchVersionDir("app/main/v1.0.0-abc/"); !ok {
+		t.Error("expected version dir match")
+	}
+}
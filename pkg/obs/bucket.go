@@ -0,0 +1,235 @@
+package obs
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// BucketACLs are the default bucket ACLs OBS accepts
+var BucketACLs = []string{
+	string(huaweicloudsdkobs.AclPrivate),
+	string(huaweicloudsdkobs.AclPublicRead),
+	string(huaweicloudsdkobs.AclPublicReadWrite),
+	string(huaweicloudsdkobs.AclPublicReadDelivery),
+	string(huaweicloudsdkobs.AclPublicReadWriteDelivery),
+}
+
+// StorageClasses are the default storage classes a bucket can have
+var StorageClasses = []string{
+	string(huaweicloudsdkobs.StorageClassStandard),
+	string(huaweicloudsdkobs.StorageClassWarm),
+	string(huaweicloudsdkobs.StorageClassCold),
+	string(huaweicloudsdkobs.StorageClassDeepArchive),
+}
+
+// BucketOptions are the settings of a new bucket; zero values leave the
+// choice to OBS
+type BucketOptions struct {
+	// Location is the region, e.g. cn-north-4; required by OBS outside the
+	// default region of an endpoint
+	Location string
+	// ACL is one of BucketACLs
+	ACL string
+	// StorageClass is one of StorageClasses, the class of objects uploaded
+	// without one
+	StorageClass string
+	// Versioning enables versioning right after creation
+	Versioning bool
+}
+
+// Validate checks the ACL and storage class
+func (o BucketOptions) Validate() error {
+	if o.ACL != "" && !containsString(BucketACLs, o.ACL) {
+		return fmt.Errorf("unknown ACL %q (use %s)", o.ACL, strings.Join(BucketACLs, ", "))
+	}
+	if o.StorageClass != "" && !containsString(StorageClasses, o.StorageClass) {
+		return fmt.Errorf("unknown storage class %q (use %s)", o.StorageClass, strings.Join(StorageClasses, ", "))
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RegionFromEndpoint returns the region of a Huawei Cloud endpoint such as
+// obs.cn-north-4.myhuaweicloud.com, or "" for other endpoints
+func RegionFromEndpoint(endpoint string) string {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) == 4 && labels[0] == "obs" && strings.HasSuffix(host, ".myhuaweicloud.com") {
+		return labels[1]
+	}
+	return ""
+}
+
+// CreateBucketWithOptions creates the bucket with the given settings.
+// Returns error if bucket already exists.
+func (c *Client) CreateBucketWithOptions(opts BucketOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	// First check if bucket exists
+	if err := c.BucketExists(); err == nil {
+		return fmt.Errorf("bucket %s already exists", c.Bucket)
+	}
+
+	// Ensure connected
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	input := &huaweicloudsdkobs.CreateBucketInput{
+		Bucket:       c.Bucket,
+		ACL:          huaweicloudsdkobs.AclType(opts.ACL),
+		StorageClass: huaweicloudsdkobs.StorageClassType(opts.StorageClass),
+	}
+	input.Location = opts.Location
+	if _, err := c.client.CreateBucket(input); err != nil {
+		return err
+	}
+
+	if opts.Versioning {
+		if err := c.SetVersioning(true); err != nil {
+			return fmt.Errorf("bucket %s created, but enabling versioning failed: %w", c.Bucket, err)
+		}
+	}
+	return nil
+}
+
+// MultipartUpload is an upload that was started but never completed or
+// aborted; its parts are stored and billed
+type MultipartUpload struct {
+	Key       string
+	UploadID  string
+	Initiated time.Time
+}
+
+// BucketContents is everything that keeps a bucket from being deleted
+type BucketContents struct {
+	// Versions holds every stored copy and delete marker of every object
+	Versions []ObjectVersion
+	Uploads  []MultipartUpload
+}
+
+// TotalSize is the size of all stored copies
+func (b *BucketContents) TotalSize() int64 {
+	var size int64
+	for _, v := range b.Versions {
+		size += v.Size
+	}
+	return size
+}
+
+// IsEmpty reports whether the bucket can be deleted as is
+func (b *BucketContents) IsEmpty() bool {
+	return len(b.Versions) == 0 && len(b.Uploads) == 0
+}
+
+// ListBucketContents lists all object versions and incomplete multipart
+// uploads in the bucket
+func (c *Client) ListBucketContents() (*BucketContents, error) {
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+
+	versions, err := c.listVersionsWithPrefix("", func(string) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	contents := &BucketContents{Versions: versions}
+
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		output, err := c.client.ListMultipartUploads(&huaweicloudsdkobs.ListMultipartUploadsInput{
+			Bucket:         c.Bucket,
+			KeyMarker:      keyMarker,
+			UploadIdMarker: uploadIDMarker,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range output.Uploads {
+			contents.Uploads = append(contents.Uploads, MultipartUpload{Key: u.Key, UploadID: u.UploadId, Initiated: u.Initiated})
+		}
+		if !output.IsTruncated {
+			break
+		}
+		keyMarker, uploadIDMarker = output.NextKeyMarker, output.NextUploadIdMarker
+	}
+	return contents, nil
+}
+
+// EmptyResult is the outcome of emptying a bucket
+type EmptyResult struct {
+	// Deleted counts removed object versions and delete markers
+	Deleted int
+	// Aborted counts aborted multipart uploads
+	Aborted int
+	// Failed lists what remains, with the reason
+	Failed []KeyError
+	// Err is the first request failure, for Classify
+	Err error
+}
+
+// EmptyBucket aborts the multipart uploads and deletes every object version
+// listed in contents. It carries on past failures, which are collected in
+// the result.
+func (c *Client) EmptyBucket(contents *BucketContents) *EmptyResult {
+	result := &EmptyResult{}
+	if err := c.ensureConnected(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	for _, u := range contents.Uploads {
+		_, err := c.client.AbortMultipartUpload(&huaweicloudsdkobs.AbortMultipartUploadInput{
+			Bucket:   c.Bucket,
+			Key:      u.Key,
+			UploadId: u.UploadID,
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, KeyError{Key: u.Key, Error: fmt.Sprintf("abort upload %s: %v", u.UploadID, err)})
+			if result.Err == nil {
+				result.Err = err
+			}
+			continue
+		}
+		result.Aborted++
+	}
+
+	objects := make([]huaweicloudsdkobs.ObjectToDelete, 0, len(contents.Versions))
+	for _, v := range contents.Versions {
+		objects = append(objects, huaweicloudsdkobs.ObjectToDelete{Key: v.Key, VersionId: v.VersionID})
+	}
+	deleted, failed, err := c.deleteObjects(objects)
+	result.Deleted = len(deleted)
+	result.Failed = append(result.Failed, failed...)
+	if result.Err == nil {
+		result.Err = err
+	}
+	return result
+}
+
+// DeleteBucket removes the bucket, which must be empty
+func (c *Client) DeleteBucket() error {
+	if err := c.ready(); err != nil {
+		return err
+	}
+
+	_, err := c.client.DeleteBucket(c.Bucket)
+	return err
+}
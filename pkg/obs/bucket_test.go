@@ -0,0 +1,108 @@
+package obs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBucketOptionsValidate(t *testing.T) {
+	tests := []struct {
+		opts BucketOptions
+		ok   bool
+	}{
+		{BucketOptions{}, true},
+		{BucketOptions{ACL: "public-read", StorageClass: "WARM"}, true},
+		{BucketOptions{ACL: "everyone"}, false},
+		{BucketOptions{StorageClass: "warm"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.opts, err, tt.ok)
+		}
+	}
+}
+
+func TestRegionFromEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"obs.cn-north-4.myhuaweicloud.com":             "cn-north-4",
+		"https://obs.ap-southeast-1.myhuaweicloud.com": "ap-southeast-1",
+		"https://obs.cn-east-3.myhuaweicloud.com:443":  "cn-east-3",
+		"http://127.0.0.1:9000":                        "",
+		"obs.example.com":                              "",
+	}
+	for endpoint, want := range tests {
+		if got := RegionFromEndpoint(endpoint); got != want {
+			t.Errorf("RegionFromEndpoint(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestCreateBucketWithOptions(t *testing.T) {
+	f, client := newFakeServer(t)
+
+	if err := client.CreateBucket(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected an existing bucket to be refused, got %v", err)
+	}
+
+	f.missing = true
+	opts := BucketOptions{Location: "cn-north-4", ACL: "public-read", StorageClass: "COLD", Versioning: true}
+	if err := client.CreateBucketWithOptions(opts); err != nil {
+		t.Fatalf("CreateBucketWithOptions failed: %v", err)
+	}
+	if !strings.Contains(string(f.createBody), "<LocationConstraint>cn-north-4</LocationConstraint>") {
+		t.Errorf("location not sent, body %q", f.createBody)
+	}
+	if acl := f.createHeaders.Get("x-amz-acl"); acl != "public-read" {
+		t.Errorf("ACL header = %q, want public-read", acl)
+	}
+	// The S3 signature names COLD as GLACIER
+	if class := f.createHeaders.Get("x-default-storage-class"); class != "GLACIER" {
+		t.Errorf("storage class header = %q, want GLACIER (headers %v)", class, f.createHeaders)
+	}
+	if f.versioning != VersioningEnabled {
+		t.Errorf("versioning = %q, want %q", f.versioning, VersioningEnabled)
+	}
+}
+
+func TestEmptyAndDeleteBucket(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.versioning = VersioningEnabled
+
+	now := time.Now()
+	f.put("v1/app", 10, now.Add(-time.Hour))
+	f.put("v1/app", 20, now)
+	f.put("v2/app", 30, now)
+	f.uploads["upload-1"] = "v3/app"
+
+	// A bucket with objects cannot be deleted
+	if err := client.DeleteBucket(); !IsBucketNotEmpty(err) {
+		t.Fatalf("expected BucketNotEmpty, got %v", err)
+	}
+
+	contents, err := client.ListBucketContents()
+	if err != nil {
+		t.Fatalf("ListBucketContents failed: %v", err)
+	}
+	if len(contents.Versions) != 3 || len(contents.Uploads) != 1 {
+		t.Fatalf("expected 3 versions and 1 upload, got %+v", contents)
+	}
+	if contents.TotalSize() != 60 {
+		t.Errorf("TotalSize = %d, want 60", contents.TotalSize())
+	}
+
+	result := client.EmptyBucket(contents)
+	if result.Err != nil || len(result.Failed) > 0 {
+		t.Fatalf("EmptyBucket failed: %+v", result)
+	}
+	if result.Deleted != 3 || result.Aborted != 1 {
+		t.Errorf("EmptyBucket deleted %d, aborted %d, want 3 and 1", result.Deleted, result.Aborted)
+	}
+
+	if err := client.DeleteBucket(); err != nil {
+		t.Fatalf("DeleteBucket failed: %v", err)
+	}
+	if err := client.BucketExists(); err == nil {
+		t.Error("bucket still exists after DeleteBucket")
+	}
+}
@@ -0,0 +1,111 @@
+package obs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// BuildNumberKey is the object holding the bucket-wide build counter
+const BuildNumberKey = ".obsput/build-number"
+
+// maxBuildNumberAttempts bounds the retries when another runner wins the race
+const maxBuildNumberAttempts = 10
+
+// NextBuildNumber atomically increments the build counter stored in the bucket
+// and returns the new value. The counter object is updated with a conditional
+// write (If-Match on the ETag, or If-None-Match for the first build), so two
+// runners can never receive the same number; conflicts are retried.
+func (c *Client) NextBuildNumber() (int64, error) {
+	if err := c.ensureConnected(); err != nil {
+		return 0, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxBuildNumberAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt*50) * time.Millisecond)
+		}
+
+		current, etag, err := c.readBuildNumber()
+		if err != nil {
+			return 0, err
+		}
+
+		next := current + 1
+		err = c.writeBuildNumber(next, etag)
+		if err == nil {
+			return next, nil
+		}
+		if !isConditionFailed(err) {
+			return 0, fmt.Errorf("update build number: %w", err)
+		}
+		lastErr = err
+	}
+	return 0, fmt.Errorf("update build number: too many conflicts: %v", lastErr)
+}
+
+// readBuildNumber returns the current counter value and its ETag.
+// A missing counter object reads as zero with an empty ETag.
+func (c *Client) readBuildNumber() (int64, string, error) {
+	input := &huaweicloudsdkobs.GetObjectInput{}
+	input.Bucket = c.Bucket
+	input.Key = BuildNumberKey
+
+	output, err := c.client.GetObject(input)
+	if err != nil {
+		var obsErr huaweicloudsdkobs.ObsError
+		if errors.As(err, &obsErr) && obsErr.StatusCode == 404 {
+			return 0, "", nil
+		}
+		return 0, "", fmt.Errorf("read build number: %w", err)
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return 0, "", fmt.Errorf("read build number: %w", err)
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid build number in %s: %v", BuildNumberKey, err)
+	}
+	return value, output.ETag, nil
+}
+
+// writeBuildNumber stores value only if the counter object still has etag
+func (c *Client) writeBuildNumber(value int64, etag string) error {
+	content := []byte(strconv.FormatInt(value, 10))
+	input := &huaweicloudsdkobs.PutObjectInput{
+		PutObjectBasicInput: huaweicloudsdkobs.PutObjectBasicInput{
+			ObjectOperationInput: huaweicloudsdkobs.ObjectOperationInput{
+				Bucket: c.Bucket,
+				Key:    BuildNumberKey,
+			},
+			ContentLength: int64(len(content)),
+		},
+		Body: strings.NewReader(string(content)),
+	}
+
+	condition := huaweicloudsdkobs.WithCustomHeader("If-None-Match", "*")
+	if etag != "" {
+		condition = huaweicloudsdkobs.WithCustomHeader("If-Match", etag)
+	}
+	_, err := c.client.PutObject(input, condition)
+	return err
+}
+
+// isConditionFailed reports whether err is a lost conditional-write race
+func isConditionFailed(err error) bool {
+	var obsErr huaweicloudsdkobs.ObsError
+	if !errors.As(err, &obsErr) {
+		return false
+	}
+	// 412 Precondition Failed; some S3 implementations answer 409 on concurrent writes
+	return obsErr.StatusCode == 412 || obsErr.StatusCode == 409
+}
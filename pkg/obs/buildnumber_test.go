@@ -0,0 +1,91 @@
+package obs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// counterServer emulates conditional writes on a single object
+type counterServer struct {
+	mu       sync.Mutex
+	value    string
+	revision int
+	// conflicts forces the next N conditional writes to fail with 412
+	conflicts int
+}
+
+func (s *counterServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	etag := fmt.Sprintf("\"rev-%d\"", s.revision)
+	switch r.Method {
+	case http.MethodGet:
+		if s.value == "" {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Header().Set("ETag", etag)
+		io.WriteString(w, s.value)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		ifMatch := r.Header.Get("If-Match")
+		ifNoneMatch := r.Header.Get("If-None-Match")
+		failed := s.conflicts > 0 ||
+			(ifMatch != "" && ifMatch != etag) ||
+			(ifNoneMatch == "*" && s.value != "")
+		if failed {
+			if s.conflicts > 0 {
+				s.conflicts--
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusPreconditionFailed)
+			io.WriteString(w, "<Error><Code>PreconditionFailed</Code></Error>")
+			return
+		}
+		s.value = string(body)
+		s.revision++
+		w.Header().Set("ETag", fmt.Sprintf("\"rev-%d\"", s.revision))
+	}
+}
+
+func TestNextBuildNumber(t *testing.T) {
+	server := httptest.NewServer(&counterServer{})
+	defer server.Close()
+
+	client := NewClient(server.URL, "bucket", "ak", "sk")
+	for want := int64(1); want <= 3; want++ {
+		got, err := client.NextBuildNumber()
+		if err != nil {
+			t.Fatalf("NextBuildNumber failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected build number %d, got %d", want, got)
+		}
+	}
+}
+
+func TestNextBuildNumberRetriesOnConflict(t *testing.T) {
+	counter := &counterServer{value: "7", conflicts: 2}
+	server := httptest.NewServer(counter)
+	defer server.Close()
+
+	client := NewClient(server.URL, "bucket", "ak", "sk")
+	got, err := client.NextBuildNumber()
+	if err != nil {
+		t.Fatalf("NextBuildNumber failed: %v", err)
+	}
+	if got != 8 {
+		t.Errorf("expected build number 8, got %d", got)
+	}
+	if strings.TrimSpace(counter.value) != "8" {
+		t.Errorf("expected stored counter 8, got %s", counter.value)
+	}
+}
@@ -13,10 +13,15 @@ import (
 	"io"
 	"net"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"obsput/pkg/layout"
+	"obsput/pkg/platform"
+
 	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 )
 
@@ -25,7 +30,23 @@ type Client struct {
 	Bucket   string
 	AK       string
 	SK       string
+	// SecurityToken accompanies temporary AK/SK issued by STS
+	SecurityToken string
+	// KeyTemplate is the object key layout; nil means layout.Default
+	KeyTemplate *layout.Template
+	// KeyValues fills template placeholders other than prefix, version and filename
+	KeyValues map[string]string
+	// TLS holds the profile's TLS settings; nil uses the system defaults
+	TLS *TLSOptions
+	// HTTP holds the proxy, timeouts and connection limits; nil uses the defaults
+	HTTP *HTTPOptions
+	// Provider, when set, supplies AK, SK and security token and renews
+	// them before they expire
+	Provider CredentialProvider
 	client   *huaweicloudsdkobs.ObsClient
+
+	// credMu guards the credentials while workers share the client
+	credMu sync.Mutex
 }
 
 func NewClient(endpoint, bucket, ak, sk string) *Client {
@@ -38,8 +59,22 @@ func NewClient(endpoint, bucket, ak, sk string) *Client {
 }
 
 func (c *Client) Connect() error {
-	obsClient, err := huaweicloudsdkobs.New(c.AK, c.SK, c.Endpoint,
+	endpoint, err := ParseEndpoint(c.Endpoint)
+	if err != nil {
+		return err
+	}
+	transport, err := c.httpTransport(endpoint)
+	if err != nil {
+		return err
+	}
+	if err := c.loadCredentials(); err != nil {
+		return err
+	}
+	obsClient, err := huaweicloudsdkobs.New(c.AK, c.SK, endpoint.URL(),
+		huaweicloudsdkobs.WithSecurityToken(c.SecurityToken),
 		huaweicloudsdkobs.WithPathStyle(true),
+		huaweicloudsdkobs.WithHttpTransport(transport),
+		huaweicloudsdkobs.WithHttpClient(c.httpClient(transport)),
 	)
 	if err != nil {
 		return err
@@ -48,27 +83,55 @@ func (c *Client) Connect() error {
 	return nil
 }
 
+// SetKeyTemplate parses and sets the object key layout
+func (c *Client) SetKeyTemplate(template string) error {
+	t, err := layout.Parse(template)
+	if err != nil {
+		return err
+	}
+	c.KeyTemplate = t
+	return nil
+}
+
+func (c *Client) keyTemplate() *layout.Template {
+	if c.KeyTemplate == nil {
+		return defaultKeyTemplate
+	}
+	return c.KeyTemplate
+}
+
+var defaultKeyTemplate = layout.MustParse(layout.Default)
+
+// ready tests the TCP connection, then connects or refreshes the credentials
+func (c *Client) ready() error {
+	if err := c.testTCPConnection(); err != nil {
+		return err
+	}
+	return c.ensureConnected()
+}
+
 func (c *Client) ensureConnected() error {
 	if c.client == nil {
 		return c.Connect()
 	}
-	return nil
+	return c.refreshCredentials()
 }
 
 // testTCPConnection tests if we can establish a TCP connection to the endpoint
 func (c *Client) testTCPConnection() error {
-	// Extract hostname from endpoint (remove http:// or https:// prefix)
-	host := strings.TrimPrefix(strings.TrimPrefix(c.Endpoint, "https://"), "http://")
-
-	// Add default port if not specified
-	if !strings.Contains(host, ":") {
-		host = host + ":443"
+	endpoint, err := ParseEndpoint(c.Endpoint)
+	if err != nil {
+		return err
 	}
 
-	// Set a short timeout for the connection test
-	conn, err := net.DialTimeout("tcp", host, 3*time.Second)
+	// Behind a proxy only the proxy can be reached directly
+	address, err := c.HTTP.dialAddress(endpoint)
 	if err != nil {
-		return fmt.Errorf("cannot connect to OBS endpoint %s: %v", c.Endpoint, err)
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", address, c.HTTP.tcpCheckTimeout())
+	if err != nil {
+		return &ConnectError{Endpoint: c.Endpoint, Err: err}
 	}
 	defer conn.Close()
 	return nil
@@ -138,21 +201,7 @@ func (c *Client) BucketExists() error {
 // CreateBucket creates a bucket if it doesn't exist
 // Returns error if bucket already exists
 func (c *Client) CreateBucket() error {
-	// First check if bucket exists
-	if err := c.BucketExists(); err == nil {
-		return fmt.Errorf("bucket %s already exists", c.Bucket)
-	}
-
-	// Ensure connected
-	if err := c.ensureConnected(); err != nil {
-		return err
-	}
-
-	input := &huaweicloudsdkobs.CreateBucketInput{
-		Bucket: c.Bucket,
-	}
-	_, err := c.client.CreateBucket(input)
-	return err
+	return c.CreateBucketWithOptions(BucketOptions{})
 }
 
 type progressListener struct {
@@ -167,11 +216,18 @@ func (p *progressListener) ProgressChanged(event *huaweicloudsdkobs.ProgressEven
 }
 
 func (c *Client) UploadFile(filePath, version, prefix string, progressCallback func(transferred int64)) (*UploadResult, error) {
+	return c.UploadFileWithMetadata(filePath, version, prefix, nil, progressCallback)
+}
+
+// UploadFileWithMetadata uploads a file and stores metadata as user-defined
+// object metadata (x-obs-meta-* / x-amz-meta-* headers)
+func (c *Client) UploadFileWithMetadata(filePath, version, prefix string, metadata map[string]string, progressCallback func(transferred int64)) (*UploadResult, error) {
 	// Test TCP connection first
 	if err := c.testTCPConnection(); err != nil {
 		return &UploadResult{
 			Success: false,
 			Error:   err.Error(),
+			Err:     err,
 		}, nil
 	}
 
@@ -180,11 +236,19 @@ func (c *Client) UploadFile(filePath, version, prefix string, progressCallback f
 		return &UploadResult{
 			Success: false,
 			Error:   err.Error(),
+			Err:     err,
 		}, nil
 	}
 
 	filename := extractFilename(filePath)
-	key := c.GetUploadKey(prefix, version, filename)
+	key, err := c.GetUploadKey(prefix, version, filename)
+	if err != nil {
+		return &UploadResult{
+			Success: false,
+			Error:   err.Error(),
+			Err:     err,
+		}, nil
+	}
 
 	// Get file size for progress reporting
 	fileInfo, err := os.Stat(filePath)
@@ -192,6 +256,7 @@ func (c *Client) UploadFile(filePath, version, prefix string, progressCallback f
 		return &UploadResult{
 			Success: false,
 			Error:   err.Error(),
+			Err:     err,
 		}, nil
 	}
 
@@ -201,6 +266,7 @@ func (c *Client) UploadFile(filePath, version, prefix string, progressCallback f
 		return &UploadResult{
 			Success: false,
 			Error:   err.Error(),
+			Err:     err,
 		}, nil
 	}
 	defer file.Close()
@@ -210,6 +276,7 @@ func (c *Client) UploadFile(filePath, version, prefix string, progressCallback f
 		return &UploadResult{
 			Success: false,
 			Error:   err.Error(),
+			Err:     err,
 		}, nil
 	}
 
@@ -220,10 +287,11 @@ func (c *Client) UploadFile(filePath, version, prefix string, progressCallback f
 	input := &huaweicloudsdkobs.PutObjectInput{
 		PutObjectBasicInput: huaweicloudsdkobs.PutObjectBasicInput{
 			ObjectOperationInput: huaweicloudsdkobs.ObjectOperationInput{
-				Bucket: c.Bucket,
-				Key:    key,
+				Bucket:   c.Bucket,
+				Key:      key,
+				Metadata: metadata,
 			},
-			ContentMD5:     md5Hash,
+			ContentMD5:    md5Hash,
 			ContentLength: int64(len(content)),
 		},
 		Body: bytes.NewReader(content),
@@ -235,6 +303,7 @@ func (c *Client) UploadFile(filePath, version, prefix string, progressCallback f
 		return &UploadResult{
 			Success: false,
 			Error:   err.Error(),
+			Err:     err,
 		}, nil
 	}
 
@@ -275,69 +344,32 @@ func (c *Client) UploadFile(filePath, version, prefix string, progressCallback f
 	}, nil
 }
 
-func (c *Client) DeleteVersion(version string) *DeleteResult {
-	// Test TCP connection first
-	if err := c.testTCPConnection(); err != nil {
-		return &DeleteResult{
-			Success: false,
-			Error:   err.Error(),
-		}
-	}
-
-	// Ensure connected
-	if err := c.ensureConnected(); err != nil {
-		return &DeleteResult{
-			Success: false,
-			Error:   err.Error(),
-		}
-	}
-
-	// Find the object with this version prefix
-	input := &huaweicloudsdkobs.ListObjectsInput{
-		ListObjsInput: huaweicloudsdkobs.ListObjsInput{
-			Prefix: version,
-		},
-		Bucket: c.Bucket,
-	}
-
-	output, err := c.client.ListObjects(input)
-	if err != nil {
-		return &DeleteResult{
-			Success: false,
-			Error:   err.Error(),
-		}
-	}
+func (c *Client) ListVersions(prefix string) ([]VersionInfo, error) {
+	return c.ListVersionsWithOptions(ListOptions{Prefix: prefix})
+}
 
-	// Delete each object with this version
-	for _, obj := range output.Contents {
-		deleteInput := &huaweicloudsdkobs.DeleteObjectInput{
-			Bucket: c.Bucket,
-			Key:    obj.Key,
-		}
-		_, err := c.client.DeleteObject(deleteInput)
-		if err != nil {
-			return &DeleteResult{
-				Success: false,
-				Error:   fmt.Sprintf("failed to delete %s: %v", obj.Key, err),
-			}
+// ListVersionsWithOptions lists version objects matching opts. Prefix and
+// branch narrow the server-side listing prefix where the key template allows;
+// the remaining filters are applied while paging, and an unsorted listing
+// stops as soon as Limit objects have been found.
+func (c *Client) ListVersionsWithOptions(opts ListOptions) ([]VersionInfo, error) {
+	tmpl := c.keyTemplate()
+	if opts.Branch != "" && !tmpl.Uses("branch") {
+		return nil, fmt.Errorf("cannot filter by branch: key template %q has no {branch}", tmpl)
+	}
+	if opts.Name != "" {
+		if _, err := path.Match(opts.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %v", opts.Name, err)
 		}
 	}
 
-	return &DeleteResult{
-		Success: true,
-		Version: version,
-	}
-}
-
-func (c *Client) ListVersions(prefix string) ([]VersionInfo, error) {
-	// Test TCP connection first
-	if err := c.testTCPConnection(); err != nil {
-		return nil, fmt.Errorf("%v", err)
+	if err := c.ready(); err != nil {
+		return nil, err
 	}
 
-	// Ensure connected
-	if err := c.ensureConnected(); err != nil {
-		return nil, err
+	listPrefix := opts.Prefix
+	if tmpl.Uses("prefix") {
+		listPrefix = tmpl.ListPrefix(map[string]string{"prefix": opts.Prefix, "branch": opts.Branch})
 	}
 
 	var allObjects []VersionInfo
@@ -346,7 +378,7 @@ func (c *Client) ListVersions(prefix string) ([]VersionInfo, error) {
 	for {
 		input := &huaweicloudsdkobs.ListObjectsInput{
 			ListObjsInput: huaweicloudsdkobs.ListObjsInput{
-				Prefix: prefix,
+				Prefix: listPrefix,
 			},
 			Bucket: c.Bucket,
 			Marker: marker,
@@ -358,19 +390,31 @@ func (c *Client) ListVersions(prefix string) ([]VersionInfo, error) {
 		}
 
 		for _, obj := range output.Contents {
-			version := c.ParseVersionFromPath(obj.Key)
-			if version != "" {
-				allObjects = append(allObjects, VersionInfo{
-					Key:     obj.Key,
-					Size:    formatSize(obj.Size),
-					Date:    obj.LastModified.Format("2006-01-02"),
-					Commit:  c.extractCommitFromVersion(version),
-					Version: version,
-					URL:     c.GetDownloadURL(obj.Key),
-				})
+			values, ok := tmpl.Match(obj.Key)
+			if ok && !isTrashKey(obj.Key) {
+				version := values["version"]
+				info := VersionInfo{
+					Key:          obj.Key,
+					Size:         formatSize(obj.Size),
+					Date:         obj.LastModified.Format("2006-01-02"),
+					Commit:       versionCommit(version),
+					Version:      version,
+					URL:          c.GetDownloadURL(obj.Key),
+					Values:       values,
+					SizeBytes:    obj.Size,
+					LastModified: obj.LastModified,
+				}
+				if opts.matches(info, tmpl.Uses("prefix")) {
+					allObjects = append(allObjects, info)
+				}
 			}
 		}
 
+		// Key order can stop at the limit; a reversed listing needs the last keys
+		if opts.Sort == "" && !opts.Reverse && opts.Limit > 0 && len(allObjects) >= opts.Limit {
+			break
+		}
+
 		// Check if there are more results
 		if !output.IsTruncated {
 			break
@@ -378,14 +422,19 @@ func (c *Client) ListVersions(prefix string) ([]VersionInfo, error) {
 		marker = output.NextMarker
 	}
 
-	return allObjects, nil
+	return opts.apply(allObjects), nil
 }
 
-func (c *Client) GetUploadKey(prefix, version, filename string) string {
-	if prefix != "" {
-		return fmt.Sprintf("%s/%s/%s", prefix, version, filename)
+// GetUploadKey renders the object key from the key template
+func (c *Client) GetUploadKey(prefix, version, filename string) (string, error) {
+	values := make(map[string]string, len(c.KeyValues)+3)
+	for k, v := range c.KeyValues {
+		values[k] = v
 	}
-	return fmt.Sprintf("%s/%s", version, filename)
+	values["prefix"] = prefix
+	values["version"] = version
+	values["filename"] = filename
+	return c.keyTemplate().Render(values)
 }
 
 func (c *Client) CalculateMD5(data []byte) string {
@@ -408,16 +457,9 @@ func (c *Client) CalculateMD5FromFile(path string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
+// GetDownloadURL returns the unsigned URL of key, in the endpoint's scheme
 func (c *Client) GetDownloadURL(key string) string {
-	// Extract hostname without protocol
-	host := strings.TrimPrefix(strings.TrimPrefix(c.Endpoint, "https://"), "http://")
-
-	// Use path style for IP addresses and localhost
-	// Virtual hosted style (bucket.host/key) doesn't work with IP addresses
-	if IsIPAddress(host) || strings.Contains(host, "localhost") {
-		return fmt.Sprintf("http://%s/%s/%s", host, c.Bucket, key)
-	}
-	return fmt.Sprintf("https://%s.%s/%s", c.Bucket, host, key)
+	return c.endpoint().ObjectURL(c.Bucket, key)
 }
 
 // IsIPAddress checks if a string is an IP address
@@ -440,9 +482,9 @@ func (c *Client) GetSignedDownloadURL(key string, durationHours int) (string, er
 	expires := durationHours * 3600 // convert hours to seconds
 
 	input := &huaweicloudsdkobs.CreateSignedUrlInput{
-		Bucket: c.Bucket,
-		Key:    key,
-		Method: huaweicloudsdkobs.HttpMethodGet,
+		Bucket:  c.Bucket,
+		Key:     key,
+		Method:  huaweicloudsdkobs.HttpMethodGet,
 		Expires: expires,
 	}
 
@@ -462,24 +504,14 @@ func CleanURL(url string) string {
 	return url
 }
 
+// ParseVersionFromPath extracts the version from an object key or a key
+// prefix ending at the version segment, according to the key template
 func (c *Client) ParseVersionFromPath(path string) string {
-	path = strings.TrimSuffix(path, "/")
-	parts := strings.Split(path, "/")
-	for i := len(parts) - 1; i >= 0; i-- {
-		if strings.HasPrefix(parts[i], "v") && strings.Contains(parts[i], "-") {
-			return parts[i]
-		}
-	}
-	return ""
-}
-
-func (c *Client) extractCommitFromVersion(version string) string {
-	// Version format: v1.0.0-commit-date-time
-	parts := strings.Split(version, "-")
-	if len(parts) >= 2 {
-		return parts[1]
+	values, ok := c.keyTemplate().MatchVersion(path)
+	if !ok {
+		return ""
 	}
-	return ""
+	return values["version"]
 }
 
 func extractFilename(path string) string {
@@ -487,8 +519,12 @@ func extractFilename(path string) string {
 }
 
 // ExtractFilenameFromKey extracts the original filename from an object key
-// Key format: prefix/version/filename or version/filename
+// laid out by the key template, falling back to the last path segment
 func (c *Client) ExtractFilenameFromKey(key string) string {
+	if values, ok := c.keyTemplate().Match(key); ok {
+		return values["filename"]
+	}
+
 	// Remove trailing slash
 	key = strings.TrimSuffix(key, "/")
 
@@ -514,21 +550,36 @@ func formatSize(size int64) string {
 }
 
 type UploadResult struct {
-	Success    bool
-	Version    string
-	Key        string
-	URL        string
-	SignedURL  string
-	MD5        string
-	Size       int64
-	Error      string
-	OBSName    string
+	Success   bool
+	Version   string
+	Key       string
+	URL       string
+	SignedURL string
+	MD5       string
+	Size      int64
+	Error     string
+	// Err is the underlying failure, for Classify
+	Err     error `json:"-"`
+	OBSName string
 }
 
 type DeleteResult struct {
 	Success bool
 	Version string
 	Error   string
+	// Err is the underlying failure, for Classify
+	Err error `json:"-"`
+	// Deleted lists the removed keys
+	Deleted []string
+	// Failed lists the keys that remain, with the reason
+	Failed []KeyError
+}
+
+// KeyError is an object that could not be deleted
+type KeyError struct {
+	Key   string
+	Code  string
+	Error string
 }
 
 type VersionInfo struct {
@@ -538,6 +589,28 @@ type VersionInfo struct {
 	Commit  string
 	Version string
 	URL     string
+	// Values holds the key template placeholders matched in Key
+	Values       map[string]string
+	SizeBytes    int64
+	LastModified time.Time
+}
+
+// Platform returns the object's platform from the key template values,
+// falling back to inferring it from the key
+func (v VersionInfo) Platform() (platform.Platform, bool) {
+	if p, ok := v.templatePlatform(); ok {
+		return p, true
+	}
+	return platform.Infer(v.Key)
+}
+
+// templatePlatform returns the platform given by {os} and {arch}
+func (v VersionInfo) templatePlatform() (platform.Platform, bool) {
+	if v.Values["os"] == "" || v.Values["arch"] == "" {
+		return platform.Platform{}, false
+	}
+	p, err := platform.Parse(v.Values["os"] + "/" + v.Values["arch"])
+	return p, err == nil
 }
 
 type BucketResult struct {
@@ -545,4 +618,6 @@ type BucketResult struct {
 	Bucket  string
 	Success bool
 	Error   string
+	// Err is the underlying failure, for Classify
+	Err error `json:"-"`
 }
@@ -46,7 +46,10 @@ func TestOBSClientEndpoint(t *testing.T) {
 func TestUploadKey(t *testing.T) {
 	client := NewClient("obs.test.com", "bucket", "ak", "sk")
 
-	key := client.GetUploadKey("prefix", "v1.0.0-abc123-20260212-143000", "myapp")
+	key, err := client.GetUploadKey("prefix", "v1.0.0-abc123-20260212-143000", "myapp")
+	if err != nil {
+		t.Fatalf("GetUploadKey failed: %v", err)
+	}
 	expected := "prefix/v1.0.0-abc123-20260212-143000/myapp"
 
 	if key != expected {
@@ -85,3 +88,25 @@ func TestDownloadURL(t *testing.T) {
 		t.Errorf("expected %s, got %s", expected, url)
 	}
 }
+
+func TestKeyTemplate(t *testing.T) {
+	client := NewClient("obs.test.com", "bucket", "ak", "sk")
+	if err := client.SetKeyTemplate("{project}/{branch}/{version}/{os}-{arch}/{filename}"); err != nil {
+		t.Fatalf("SetKeyTemplate failed: %v", err)
+	}
+	client.KeyValues = map[string]string{"project": "tool", "branch": "main", "os": "darwin", "arch": "arm64"}
+
+	key, err := client.GetUploadKey("", "v1.0.0-abc123-20260212-143000", "tool")
+	if err != nil {
+		t.Fatalf("GetUploadKey failed: %v", err)
+	}
+	if key != "tool/main/v1.0.0-abc123-20260212-143000/darwin-arm64/tool" {
+		t.Errorf("unexpected key %s", key)
+	}
+	if v := client.ParseVersionFromPath(key); v != "v1.0.0-abc123-20260212-143000" {
+		t.Errorf("unexpected version %s", v)
+	}
+	if f := client.ExtractFilenameFromKey(key); f != "tool" {
+		t.Errorf("unexpected filename %s", f)
+	}
+}
@@ -0,0 +1,168 @@
+package obs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialRefreshMargin is how long before expiry credentials are renewed,
+// so a request is not signed with keys that expire while it is in flight
+const credentialRefreshMargin = 2 * time.Minute
+
+// credentialProcessTimeout limits one run of a credential process
+const credentialProcessTimeout = time.Minute
+
+// Credentials are an access key pair, with the security token and expiry of
+// temporary credentials
+type Credentials struct {
+	AK            string
+	SK            string
+	SecurityToken string
+	// Expiration is zero for credentials that do not expire
+	Expiration time.Time
+}
+
+// expiresWithin reports whether the credentials expire within d
+func (c Credentials) expiresWithin(d time.Duration) bool {
+	return !c.Expiration.IsZero() && time.Until(c.Expiration) < d
+}
+
+// CredentialProvider supplies credentials that may change over time. The
+// client asks before each operation and switches to new credentials.
+type CredentialProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// ProcessProvider runs an external command that prints credentials as JSON:
+//
+//	{"ak": "...", "sk": "...", "security_token": "...", "expiration": "2026-01-02T15:04:05Z"}
+//
+// security_token and expiration are optional. The result is cached until
+// shortly before it expires; providers with the same command share it.
+type ProcessProvider struct {
+	Command string
+}
+
+// NewProcessProvider returns a provider running command with the shell
+func NewProcessProvider(command string) *ProcessProvider {
+	return &ProcessProvider{Command: command}
+}
+
+// processCache holds the last credentials of each command
+var processCache = struct {
+	sync.Mutex
+	creds map[string]Credentials
+}{creds: make(map[string]Credentials)}
+
+// Credentials returns the cached credentials, running the command when
+// there are none or they are about to expire
+func (p *ProcessProvider) Credentials() (Credentials, error) {
+	processCache.Lock()
+	defer processCache.Unlock()
+
+	if creds, ok := processCache.creds[p.Command]; ok && !creds.expiresWithin(credentialRefreshMargin) {
+		return creds, nil
+	}
+	creds, err := p.run()
+	if err != nil {
+		return Credentials{}, err
+	}
+	processCache.creds[p.Command] = creds
+	return creds, nil
+}
+
+// processOutput is the JSON printed by a credential process
+type processOutput struct {
+	AK            string `json:"ak"`
+	SK            string `json:"sk"`
+	SecurityToken string `json:"security_token"`
+	Expiration    string `json:"expiration"`
+}
+
+func (p *ProcessProvider) run() (Credentials, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProcessTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.Command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	// Messages and prompts of the command go to the user
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return Credentials{}, fmt.Errorf("credential_process timed out after %s", credentialProcessTimeout)
+		}
+		return Credentials{}, fmt.Errorf("credential_process failed: %v", err)
+	}
+
+	var out processOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return Credentials{}, fmt.Errorf("credential_process printed invalid JSON: %v", err)
+	}
+	creds := Credentials{
+		AK:            strings.TrimSpace(out.AK),
+		SK:            strings.TrimSpace(out.SK),
+		SecurityToken: strings.TrimSpace(out.SecurityToken),
+	}
+	if creds.AK == "" || creds.SK == "" {
+		return Credentials{}, fmt.Errorf("credential_process printed no ak or sk")
+	}
+	if out.Expiration != "" {
+		exp, err := time.Parse(time.RFC3339, out.Expiration)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("credential_process printed an invalid expiration %q: use RFC 3339, e.g. 2026-01-02T15:04:05Z", out.Expiration)
+		}
+		if time.Until(exp) <= 0 {
+			return Credentials{}, fmt.Errorf("credential_process returned credentials that expired at %s", exp.Format(time.RFC3339))
+		}
+		creds.Expiration = exp
+	}
+	return creds, nil
+}
+
+// loadCredentials takes the keys from the provider, if any
+func (c *Client) loadCredentials() error {
+	if c.Provider == nil {
+		return nil
+	}
+	creds, err := c.Provider.Credentials()
+	if err != nil {
+		return err
+	}
+	c.AK, c.SK, c.SecurityToken = creds.AK, creds.SK, creds.SecurityToken
+	return nil
+}
+
+// refreshCredentials switches the connected client to the provider's
+// current credentials when they changed
+func (c *Client) refreshCredentials() error {
+	if c.Provider == nil {
+		return nil
+	}
+	c.credMu.Lock()
+	defer c.credMu.Unlock()
+	creds, err := c.Provider.Credentials()
+	if err != nil {
+		return fmt.Errorf("refresh credentials: %w", err)
+	}
+	if creds.AK == c.AK && creds.SK == c.SK && creds.SecurityToken == c.SecurityToken {
+		return nil
+	}
+	c.AK, c.SK, c.SecurityToken = creds.AK, creds.SK, creds.SecurityToken
+	c.client.Refresh(c.AK, c.SK, c.SecurityToken)
+	return nil
+}
@@ -0,0 +1,146 @@
+package obs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// credentialScript writes a credential process that counts its runs in a
+// file and prints the given JSON
+func credentialScript(t *testing.T, output string) (command, counter string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("credential process tests use sh")
+	}
+	dir := t.TempDir()
+	counter = filepath.Join(dir, "runs")
+	script := filepath.Join(dir, "creds.sh")
+	body := fmt.Sprintf("#!/bin/sh\necho run >> %q\ncat <<'EOF'\n%s\nEOF\n", counter, output)
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatalf("write script failed: %v", err)
+	}
+	return script, counter
+}
+
+func countRuns(t *testing.T, counter string) int {
+	t.Helper()
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(data), "run")
+}
+
+func TestProcessProvider(t *testing.T) {
+	exp := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	command, counter := credentialScript(t, `{"ak": "tmp-ak", "sk": "tmp-sk", "security_token": "token", "expiration": "`+exp+`"}`)
+
+	provider := NewProcessProvider(command)
+	creds, err := provider.Credentials()
+	if err != nil {
+		t.Fatalf("Credentials failed: %v", err)
+	}
+	if creds.AK != "tmp-ak" || creds.SK != "tmp-sk" || creds.SecurityToken != "token" || creds.Expiration.IsZero() {
+		t.Errorf("unexpected credentials %+v", creds)
+	}
+
+	// Cached until shortly before expiry, also for other providers
+	if _, err := NewProcessProvider(command).Credentials(); err != nil {
+		t.Fatalf("Credentials failed: %v", err)
+	}
+	if runs := countRuns(t, counter); runs != 1 {
+		t.Errorf("process ran %d times, want 1", runs)
+	}
+}
+
+func TestProcessProviderRefreshesExpiring(t *testing.T) {
+	// Inside the refresh margin, so every call runs the process again
+	exp := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	command, counter := credentialScript(t, `{"ak": "ak", "sk": "sk", "expiration": "`+exp+`"}`)
+
+	provider := NewProcessProvider(command)
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Credentials(); err != nil {
+			t.Fatalf("Credentials failed: %v", err)
+		}
+	}
+	if runs := countRuns(t, counter); runs != 2 {
+		t.Errorf("process ran %d times, want 2", runs)
+	}
+}
+
+func TestProcessProviderErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":       "ak=1",
+		"no sk":          `{"ak": "ak"}`,
+		"bad expiration": `{"ak": "ak", "sk": "sk", "expiration": "tomorrow"}`,
+		"expired":        `{"ak": "ak", "sk": "sk", "expiration": "2001-01-01T00:00:00Z"}`,
+	}
+	for name, output := range tests {
+		command, _ := credentialScript(t, output)
+		if _, err := NewProcessProvider(command).Credentials(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	command, _ := credentialScript(t, `{"ak": "ak", "sk": "sk"}`)
+	if _, err := NewProcessProvider(command + "; exit 3").Credentials(); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("expected the exit status in the error, got %v", err)
+	}
+}
+
+// stubProvider hands out the credentials it holds
+type stubProvider struct {
+	creds Credentials
+	calls int
+}
+
+func (p *stubProvider) Credentials() (Credentials, error) {
+	p.calls++
+	return p.creds, nil
+}
+
+func TestClientRefreshesCredentials(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.put("v1/app", 10, time.Now())
+
+	provider := &stubProvider{creds: Credentials{AK: "ak-1", SK: "sk-1", SecurityToken: "token-1"}}
+	client.AK, client.SK = "", ""
+	client.Provider = provider
+	if err := client.BucketExists(); err != nil {
+		t.Fatalf("BucketExists failed: %v", err)
+	}
+	if client.AK != "ak-1" || client.SecurityToken != "token-1" {
+		t.Errorf("provider credentials not used: %s %s", client.AK, client.SecurityToken)
+	}
+
+	provider.creds = Credentials{AK: "ak-2", SK: "sk-2", SecurityToken: "token-2"}
+	if err := client.BucketExists(); err != nil {
+		t.Fatalf("BucketExists failed: %v", err)
+	}
+	if client.AK != "ak-2" || client.SecurityToken != "token-2" {
+		t.Errorf("credentials not refreshed: %s %s", client.AK, client.SecurityToken)
+	}
+	if f.lastAuth == "" || !strings.Contains(f.lastAuth, "ak-2") {
+		t.Errorf("request not signed with the new key: %q", f.lastAuth)
+	}
+	if f.lastToken != "token-2" {
+		t.Errorf("security token header = %q, want token-2", f.lastToken)
+	}
+}
+
+func TestClientSendsSecurityToken(t *testing.T) {
+	f, client := newFakeServer(t)
+	client.SecurityToken = "sts-token"
+	if err := client.BucketExists(); err != nil {
+		t.Fatalf("BucketExists failed: %v", err)
+	}
+	if f.lastToken != "sts-token" {
+		t.Errorf("security token header = %q, want sts-token", f.lastToken)
+	}
+}
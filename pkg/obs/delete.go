@@ -0,0 +1,142 @@
+package obs
+
+import (
+	"fmt"
+	"sync"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// maxDeleteBatch is the most keys one DeleteObjects request may carry; a
+// variable so tests can force several batches
+var maxDeleteBatch = 1000
+
+// DefaultDeleteConcurrency is how many versions DeleteVersionGroups removes at once
+const DefaultDeleteConcurrency = 4
+
+// failedDelete reports a version none of whose objects could be removed
+func failedDelete(version string, err error) *DeleteResult {
+	return &DeleteResult{
+		Success: false,
+		Version: version,
+		Error:   err.Error(),
+		Err:     err,
+	}
+}
+
+// deleteKeys removes keys with multi-object deletes of up to maxDeleteBatch
+// keys. A failed batch marks its keys as failed and the next batch is still
+// sent.
+func (c *Client) deleteKeys(version string, keys []string) *DeleteResult {
+	result := &DeleteResult{Version: version}
+	if len(keys) > 0 {
+		if err := c.ensureConnected(); err != nil {
+			return failedDelete(version, err)
+		}
+	}
+
+	objects := make([]huaweicloudsdkobs.ObjectToDelete, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, huaweicloudsdkobs.ObjectToDelete{Key: key})
+	}
+	result.Deleted, result.Failed, result.Err = c.deleteObjects(objects)
+
+	result.Success = len(result.Failed) == 0
+	if !result.Success {
+		result.Error = fmt.Sprintf("%d of %d object(s) not deleted", len(result.Failed), len(keys))
+		if result.Err != nil {
+			result.Error += fmt.Sprintf(": %v", result.Err)
+		} else {
+			result.Error += fmt.Sprintf(": %s", result.Failed[0].Error)
+		}
+	}
+	return result
+}
+
+// deleteObjects sends objects in batches of maxDeleteBatch and returns the
+// deleted keys, the failed ones and the first request error
+func (c *Client) deleteObjects(objects []huaweicloudsdkobs.ObjectToDelete) ([]string, []KeyError, error) {
+	var deleted []string
+	var failed []KeyError
+	var firstErr error
+	for start := 0; start < len(objects); start += maxDeleteBatch {
+		end := start + maxDeleteBatch
+		if end > len(objects) {
+			end = len(objects)
+		}
+		batch := objects[start:end]
+
+		input := &huaweicloudsdkobs.DeleteObjectsInput{
+			Bucket:  c.Bucket,
+			Quiet:   false,
+			Objects: batch,
+		}
+
+		output, err := c.client.DeleteObjects(input)
+		if err != nil {
+			for _, obj := range batch {
+				failed = append(failed, KeyError{Key: obj.Key, Error: err.Error()})
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		for _, d := range output.Deleteds {
+			deleted = append(deleted, d.Key)
+		}
+		for _, e := range output.Errors {
+			failed = append(failed, KeyError{Key: e.Key, Code: e.Code, Error: e.Message})
+		}
+	}
+	return deleted, failed, firstErr
+}
+
+// DeleteVersionGroup deletes the objects of a version as listed in g, and
+// only those: objects uploaded after the listing are left alone. Objects are
+// removed in batches; failures do not stop the remaining batches, and the
+// result lists every key deleted or left behind.
+func (c *Client) DeleteVersionGroup(g VersionGroup) *DeleteResult {
+	keys := make([]string, 0, len(g.Files))
+	for _, f := range g.Files {
+		keys = append(keys, f.Key)
+	}
+	return c.deleteKeys(g.Version, keys)
+}
+
+// DeleteVersionGroups deletes several versions, at most concurrency at a time.
+// Results are in the order of groups; one failed version does not stop others.
+func (c *Client) DeleteVersionGroups(groups []VersionGroup, concurrency int) []*DeleteResult {
+	return c.forEachVersionGroup(groups, concurrency, c.DeleteVersionGroup)
+}
+
+// forEachVersionGroup runs fn on groups with at most concurrency running at once
+func (c *Client) forEachVersionGroup(groups []VersionGroup, concurrency int, fn func(VersionGroup) *DeleteResult) []*DeleteResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	// Connect once up front so workers share the SDK client
+	if err := c.ensureConnected(); err != nil {
+		results := make([]*DeleteResult, len(groups))
+		for i, g := range groups {
+			results[i] = failedDelete(g.Version, err)
+		}
+		return results
+	}
+
+	results := make([]*DeleteResult, len(groups))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, g := range groups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, g VersionGroup) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = fn(g)
+		}(i, g)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,201 @@
+package obs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// listGroups lists the files of dirs, as delete and prune do before asking
+func listGroups(t *testing.T, client *Client, dirs ...VersionDir) []VersionGroup {
+	t.Helper()
+	groups := make([]VersionGroup, 0, len(dirs))
+	for _, dir := range dirs {
+		files, err := client.ListVersionFiles(dir)
+		if err != nil {
+			t.Fatalf("ListVersionFiles(%s) failed: %v", dir.Prefix, err)
+		}
+		g := VersionGroup{Version: dir.Version, Prefix: dir.Prefix}
+		for _, f := range files {
+			g.add(f)
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+func TestDeleteVersionGroupBatches(t *testing.T) {
+	f, client := newFakeServer(t)
+	day := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		f.put(fmt.Sprintf("v1.0.0-aaa111-20260210-120000-1/file-%d", i), 1, day)
+	}
+
+	old := maxDeleteBatch
+	maxDeleteBatch = 2
+	t.Cleanup(func() { maxDeleteBatch = old })
+
+	g := listGroups(t, client, VersionDir{Version: "v1.0.0-aaa111-20260210-120000-1", Prefix: "v1.0.0-aaa111-20260210-120000-1/"})[0]
+	result := client.DeleteVersionGroup(g)
+	if !result.Success {
+		t.Fatalf("DeleteVersionGroup failed: %s", result.Error)
+	}
+	if len(result.Deleted) != 5 {
+		t.Errorf("expected 5 deleted keys, got %v", result.Deleted)
+	}
+	if f.deleteCalls != 3 {
+		t.Errorf("expected 3 batches, got %d", f.deleteCalls)
+	}
+	if len(f.keys()) != 0 {
+		t.Errorf("expected bucket to be empty, got %v", f.keys())
+	}
+}
+
+func TestDeleteVersionGroupContinuesPastFailures(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+	denied := "v1.0.0-aaa111-20260210-120000-1/app-darwin-arm64"
+	f.denyDelete = map[string]bool{denied: true}
+
+	g := listGroups(t, client, VersionDir{Version: "v1.0.0-aaa111-20260210-120000-1", Prefix: "v1.0.0-aaa111-20260210-120000-1/"})[0]
+	result := client.DeleteVersionGroup(g)
+	if result.Success {
+		t.Fatal("expected partial failure")
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != "v1.0.0-aaa111-20260210-120000-1/app-linux-amd64" {
+		t.Errorf("expected the allowed key to be deleted, got %v", result.Deleted)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Key != denied || result.Failed[0].Code != "AccessDenied" {
+		t.Errorf("expected %s to be reported as remaining, got %+v", denied, result.Failed)
+	}
+	if result.Error == "" {
+		t.Error("expected an error message")
+	}
+}
+
+func TestDeleteVersionGroups(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+	f.denyDelete = map[string]bool{"v1.0.0-bbb222-20260211-120000-1/app-linux-amd64": true}
+
+	dirs := []VersionDir{
+		{Version: "v1.0.0-aaa111-20260210-120000-1", Prefix: "v1.0.0-aaa111-20260210-120000-1/"},
+		{Version: "v1.0.0-bbb222-20260211-120000-1", Prefix: "v1.0.0-bbb222-20260211-120000-1/"},
+		{Version: "v1.0.0-ccc333-20260212-120000-1", Prefix: "releases/v1.0.0-ccc333-20260212-120000-1/"},
+	}
+	results := client.DeleteVersionGroups(listGroups(t, client, dirs...), 2)
+	if len(results) != len(dirs) {
+		t.Fatalf("expected %d results, got %d", len(dirs), len(results))
+	}
+	for i, dir := range dirs {
+		if results[i].Version != dir.Version {
+			t.Errorf("result %d is for %s, want %s", i, results[i].Version, dir.Version)
+		}
+	}
+	if !results[0].Success || results[1].Success || !results[2].Success {
+		t.Errorf("expected only the second version to fail, got %v %v %v", results[0].Success, results[1].Success, results[2].Success)
+	}
+	want := []string{".obsput/build-number", "releases/nightly/v1.0.0-ddd444-20260213-120000-1/app", "v1.0.0-bbb222-20260211-120000-1/app-linux-amd64"}
+	if got := f.keys(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v to remain, got %v", want, got)
+	}
+}
+
+func TestDeleteVersionGroupsHonoursPrefix(t *testing.T) {
+	f, client := newFakeServer(t)
+	day := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	version := "v1.0.0-aaa111-20260210-120000-1"
+	f.put("releases/"+version+"/app", 1, day)
+	f.put("nightly/"+version+"/app", 1, day)
+	// Shares the version string as a key prefix but is a different version
+	f.put("releases/"+version+"0/app", 1, day)
+
+	// delete selects the directories of the version from the listing
+	selectVersion := func(prefix string) []VersionDir {
+		dirs, err := client.ListVersionDirs(prefix)
+		if err != nil {
+			t.Fatalf("ListVersionDirs failed: %v", err)
+		}
+		var selected []VersionDir
+		for _, d := range dirs {
+			if d.Version == version {
+				selected = append(selected, d)
+			}
+		}
+		return selected
+	}
+
+	results := client.DeleteVersionGroups(listGroups(t, client, selectVersion("releases")...), DefaultDeleteConcurrency)
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("expected one successful delete, got %+v", results)
+	}
+	if len(results[0].Deleted) != 1 || results[0].Deleted[0] != "releases/"+version+"/app" {
+		t.Errorf("expected only the releases object to be deleted, got %v", results[0].Deleted)
+	}
+	want := []string{"nightly/" + version + "/app", "releases/" + version + "0/app"}
+	if got := f.keys(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v to remain, got %v", want, got)
+	}
+
+	// Without a prefix the version is deleted wherever it was uploaded
+	results = client.DeleteVersionGroups(listGroups(t, client, selectVersion("")...), DefaultDeleteConcurrency)
+	if len(results) != 1 || !results[0].Success || len(results[0].Deleted) != 1 {
+		t.Errorf("expected the nightly object to be deleted, got %+v", results)
+	}
+	if dirs := selectVersion(""); len(dirs) != 0 {
+		t.Errorf("expected the version to be gone, got %+v", dirs)
+	}
+	want = []string{"releases/" + version + "0/app"}
+	if got := f.keys(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v to remain, got %v", want, got)
+	}
+}
+
+func TestDeleteVersionGroupsUnderPrefix(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+
+	dirs, err := client.ListVersionDirs("releases/nightly")
+	if err != nil {
+		t.Fatalf("ListVersionDirs failed: %v", err)
+	}
+	if len(dirs) != 1 {
+		t.Fatalf("expected one version under releases/nightly, got %+v", dirs)
+	}
+	for _, result := range client.DeleteVersionGroups(listGroups(t, client, dirs...), DefaultDeleteConcurrency) {
+		if !result.Success {
+			t.Errorf("delete failed: %s", result.Error)
+		}
+	}
+	for _, key := range f.keys() {
+		if key == "releases/nightly/v1.0.0-ddd444-20260213-120000-1/app" {
+			t.Errorf("expected %s to be deleted", key)
+		}
+	}
+	if len(f.keys()) != 5 {
+		t.Errorf("expected other versions to remain, got %v", f.keys())
+	}
+}
+
+func TestDeleteVersionGroupKeepsLaterUploads(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+	dir := VersionDir{Version: "v1.0.0-aaa111-20260210-120000-1", Prefix: "v1.0.0-aaa111-20260210-120000-1/"}
+	g := listGroups(t, client, dir)[0]
+	f.listCalls = 0
+
+	// Uploaded after the plan was shown
+	late := dir.Prefix + "app-windows-amd64.exe"
+	f.put(late, 1, time.Now())
+
+	result := client.DeleteVersionGroup(g)
+	if !result.Success || len(result.Deleted) != 2 {
+		t.Fatalf("expected the 2 listed objects to be deleted, got %+v", result)
+	}
+	if _, ok := f.objects[late]; !ok {
+		t.Errorf("expected %s, uploaded after the listing, to remain", late)
+	}
+	if f.listCalls != 0 {
+		t.Errorf("expected no listing while deleting, got %d", f.listCalls)
+	}
+}
@@ -0,0 +1,389 @@
+package obs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// Check results
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+	// CheckSkip is a check that could not run because an earlier one failed
+	CheckSkip = "skip"
+)
+
+// DoctorProbePrefix is where Diagnose writes its temporary probe object
+const DoctorProbePrefix = ".obsput-doctor/"
+
+// OBS rejects requests whose time is more than 15 minutes off
+const (
+	maxClockSkew  = 15 * time.Minute
+	warnClockSkew = time.Minute
+)
+
+// Check is the outcome of one diagnostic step
+type Check struct {
+	Name   string
+	Status string
+	// Detail says what was found
+	Detail string
+	// Hint suggests a fix for a failed or warning check
+	Hint string
+	// Err is the underlying failure, for Classify
+	Err error
+}
+
+// Failed reports whether the check failed
+func (c Check) Failed() bool {
+	return c.Status == CheckFail
+}
+
+// Diagnose checks, in order, DNS, TCP, TLS, clock skew, credentials, the
+// bucket, and list/put/get/delete permissions with a temporary probe object.
+// A check that others depend on skips them when it fails.
+func (c *Client) Diagnose() []Check {
+	var checks []Check
+	add := func(check Check) bool {
+		checks = append(checks, check)
+		return !check.Failed()
+	}
+	// skip marks the checks that depend on the last, failed one
+	skip := func(names ...string) []Check {
+		reason := "needs " + checks[len(checks)-1].Name
+		for _, name := range names {
+			checks = append(checks, Check{Name: name, Status: CheckSkip, Detail: reason})
+		}
+		return checks
+	}
+
+	endpoint, err := ParseEndpoint(c.Endpoint)
+	if err != nil {
+		add(Check{Name: "endpoint", Status: CheckFail, Detail: err.Error(), Err: err, Hint: "Fix endpoint in the profile"})
+		return skip("dns", "tcp", "tls", "clock", "credentials", "bucket", "list", "put", "get", "delete")
+	}
+	transport, err := c.httpTransport(endpoint)
+	if err != nil {
+		add(Check{Name: "tls", Status: CheckFail, Detail: err.Error(), Err: err, Hint: "Fix the tls settings of the profile"})
+		return skip("dns", "tcp", "clock", "credentials", "bucket", "list", "put", "get", "delete")
+	}
+	proxy, err := c.HTTP.proxyFor(endpoint)
+	if err != nil {
+		add(Check{Name: "proxy", Status: CheckFail, Detail: err.Error(), Err: err, Hint: "Fix http.proxy in the profile or HTTPS_PROXY"})
+		return skip("dns", "tcp", "tls", "clock", "credentials", "bucket", "list", "put", "get", "delete")
+	}
+
+	// Behind a proxy, the proxy resolves and connects to the endpoint
+	if proxy == nil {
+		if !add(checkDNS(endpoint.Host)) {
+			return skip("tcp", "tls", "clock", "credentials", "bucket", "list", "put", "get", "delete")
+		}
+	} else {
+		add(Check{Name: "dns", Status: CheckSkip, Detail: "resolved by proxy " + redactProxy(proxy.String())})
+	}
+	address, _ := c.HTTP.dialAddress(endpoint)
+	if !add(checkTCP(address, c.HTTP.tcpCheckTimeout(), proxy != nil)) {
+		return skip("tls", "clock", "credentials", "bucket", "list", "put", "get", "delete")
+	}
+	if endpoint.Scheme == "https" {
+		add(checkTLS(endpoint.URL()+"/", transport))
+	} else {
+		add(Check{Name: "tls", Status: CheckSkip, Detail: "plain HTTP endpoint"})
+	}
+	add(checkClock(endpoint.URL()+"/", transport))
+
+	if err := c.ensureConnected(); err != nil {
+		add(Check{Name: "credentials", Status: CheckFail, Detail: err.Error(), Err: err})
+		return skip("bucket", "list", "put", "get", "delete")
+	}
+	if !add(c.checkCredentials()) {
+		return skip("bucket", "list", "put", "get", "delete")
+	}
+	if !add(c.checkBucket()) {
+		return skip("list", "put", "get", "delete")
+	}
+	add(c.checkList())
+
+	key := DoctorProbePrefix + probeName()
+	data := []byte("obsput doctor probe " + time.Now().UTC().Format(time.RFC3339) + "\n")
+	if !add(c.checkPut(key, data)) {
+		return skip("get", "delete")
+	}
+	add(c.checkGet(key, data))
+	add(c.checkDelete(key))
+	return checks
+}
+
+func checkDNS(host string) Check {
+	check := Check{Name: "dns"}
+	if net.ParseIP(host) != nil {
+		check.Status, check.Detail = CheckPass, host+" is an IP address"
+		return check
+	}
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		check.Status, check.Detail, check.Err = CheckFail, err.Error(), err
+		check.Hint = "Check the endpoint host name and the DNS servers of this machine"
+		return check
+	}
+	check.Status, check.Detail = CheckPass, fmt.Sprintf("%s resolves to %s", host, strings.Join(addrs, ", "))
+	return check
+}
+
+// checkTCP connects to address, the endpoint or its proxy
+func checkTCP(address string, timeout time.Duration, viaProxy bool) Check {
+	check := Check{Name: "tcp"}
+	target := address
+	if viaProxy {
+		target = "proxy " + address
+	}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		check.Status, check.Detail, check.Err = CheckFail, err.Error(), &ConnectError{Endpoint: address, Err: err}
+		check.Hint = "Check the endpoint port, firewalls and proxies between this machine and OBS"
+		if viaProxy {
+			check.Hint = "Check http.proxy in the profile, or HTTPS_PROXY and NO_PROXY"
+		}
+		return check
+	}
+	conn.Close()
+	check.Status, check.Detail = CheckPass, fmt.Sprintf("connected to %s in %s", target, time.Since(start).Round(time.Millisecond))
+	return check
+}
+
+// checkTLS makes a request with the profile's transport, so the handshake
+// goes through the proxy and uses the profile's certificates
+func checkTLS(endpointURL string, transport *http.Transport) Check {
+	check := Check{Name: "tls"}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	resp, err := client.Head(endpointURL)
+	if err != nil {
+		check.Status, check.Detail, check.Err = CheckFail, err.Error(), err
+		check.Hint = "The endpoint certificate is not trusted; set tls.ca_bundle in the profile to the CA that signed it"
+		return check
+	}
+	resp.Body.Close()
+	if resp.TLS == nil {
+		check.Status, check.Detail = CheckSkip, "no TLS connection"
+		return check
+	}
+
+	state := resp.TLS
+	check.Status = CheckPass
+	check.Detail = tls.VersionName(state.Version)
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		check.Detail += fmt.Sprintf(", certificate for %s valid until %s", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+		if time.Until(cert.NotAfter) < 14*24*time.Hour {
+			check.Status = CheckWarn
+			check.Hint = "The endpoint certificate expires soon"
+		}
+	}
+	if transport.TLSClientConfig.InsecureSkipVerify {
+		check.Status = CheckWarn
+		check.Hint = "tls.insecure_skip_verify is set: the certificate is not verified"
+	}
+	return check
+}
+
+// checkClock compares the local time with the Date header of the endpoint
+func checkClock(endpointURL string, transport *http.Transport) Check {
+	check := Check{Name: "clock"}
+	// Only the Date header is read; the tls check reports certificate problems
+	insecure := transport.Clone()
+	insecure.TLSClientConfig.InsecureSkipVerify = true
+	client := &http.Client{Timeout: 10 * time.Second, Transport: insecure}
+	before := time.Now()
+	resp, err := client.Head(endpointURL)
+	if err != nil {
+		check.Status, check.Detail, check.Err = CheckSkip, err.Error(), err
+		return check
+	}
+	resp.Body.Close()
+	after := time.Now()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		check.Status, check.Detail = CheckSkip, "the endpoint sent no Date header"
+		return check
+	}
+	// The Date header has second precision; compare with the middle of the request
+	local := before.Add(after.Sub(before) / 2)
+	skew := local.Sub(serverTime).Round(time.Second)
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	check.Detail = fmt.Sprintf("local clock is %s off the server", skew)
+	switch {
+	case abs > maxClockSkew:
+		check.Status = CheckFail
+		check.Err = fmt.Errorf("clock skew %s", skew)
+		check.Hint = "OBS rejects requests more than 15 minutes off; sync the system clock (NTP)"
+	case abs > warnClockSkew:
+		check.Status = CheckWarn
+		check.Hint = "Sync the system clock (NTP) before the skew reaches 15 minutes"
+	default:
+		check.Status = CheckPass
+	}
+	return check
+}
+
+// obsErrorCode returns the OBS error code of err, or ""
+func obsErrorCode(err error) (string, int) {
+	var obsErr huaweicloudsdkobs.ObsError
+	if errors.As(err, &obsErr) {
+		return obsErr.Code, obsErr.StatusCode
+	}
+	return "", 0
+}
+
+// checkCredentials lists the account's buckets; a refusal that is not about
+// the signature still proves the keys are valid
+func (c *Client) checkCredentials() Check {
+	check := Check{Name: "credentials"}
+	_, err := c.client.ListBuckets(nil)
+	if err == nil {
+		check.Status, check.Detail = CheckPass, "access key accepted"
+		return check
+	}
+	code, status := obsErrorCode(err)
+	switch code {
+	case "InvalidAccessKeyId":
+		check.Hint = "The access key does not exist; check ak in the profile"
+	case "SignatureDoesNotMatch":
+		check.Hint = "The secret key does not match the access key; check sk in the profile"
+	case "ExpiredToken", "InvalidToken", "TokenRefreshRequired":
+		check.Hint = "The temporary credentials expired; renew security_token or the credential_process output"
+	case "RequestTimeTooSkewed":
+		check.Hint = "Sync the system clock (NTP)"
+	default:
+		if status == http.StatusForbidden || status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+			check.Status, check.Detail = CheckPass, fmt.Sprintf("access key accepted (listing buckets refused: %s)", describeStatus(code, status))
+			return check
+		}
+		check.Hint = "Unexpected response; check the endpoint"
+	}
+	check.Status, check.Detail, check.Err = CheckFail, err.Error(), err
+	return check
+}
+
+func (c *Client) checkBucket() Check {
+	check := Check{Name: "bucket"}
+	_, err := c.client.HeadBucket(c.Bucket)
+	if err == nil {
+		check.Status, check.Detail = CheckPass, fmt.Sprintf("bucket %s exists", c.Bucket)
+		return check
+	}
+	check.Status, check.Detail, check.Err = CheckFail, err.Error(), err
+	switch _, status := obsErrorCode(err); status {
+	case http.StatusNotFound:
+		check.Hint = "The bucket does not exist; create it with obsput obs mb, or fix bucket in the profile"
+	case http.StatusForbidden:
+		check.Hint = "The bucket belongs to another account, or the keys lack access to it"
+	default:
+		check.Hint = "Unexpected response; check the endpoint and bucket name"
+	}
+	return check
+}
+
+func (c *Client) checkList() Check {
+	check := Check{Name: "list"}
+	input := &huaweicloudsdkobs.ListObjectsInput{Bucket: c.Bucket}
+	input.MaxKeys = 1
+	if _, err := c.client.ListObjects(input); err != nil {
+		return permissionFailure(check, "obs:bucket:ListBucket", err)
+	}
+	check.Status, check.Detail = CheckPass, "objects can be listed"
+	return check
+}
+
+func (c *Client) checkPut(key string, data []byte) Check {
+	check := Check{Name: "put"}
+	input := &huaweicloudsdkobs.PutObjectInput{}
+	input.Bucket = c.Bucket
+	input.Key = key
+	input.Body = bytes.NewReader(data)
+	if _, err := c.client.PutObject(input); err != nil {
+		return permissionFailure(check, "obs:object:PutObject", err)
+	}
+	check.Status, check.Detail = CheckPass, "wrote "+key
+	return check
+}
+
+func (c *Client) checkGet(key string, data []byte) Check {
+	check := Check{Name: "get"}
+	input := &huaweicloudsdkobs.GetObjectInput{}
+	input.Bucket = c.Bucket
+	input.Key = key
+	output, err := c.client.GetObject(input)
+	if err != nil {
+		return permissionFailure(check, "obs:object:GetObject", err)
+	}
+	defer output.Body.Close()
+	got, err := io.ReadAll(output.Body)
+	if err != nil {
+		check.Status, check.Detail, check.Err = CheckFail, err.Error(), err
+		return check
+	}
+	if !bytes.Equal(got, data) {
+		check.Status, check.Detail = CheckFail, "the probe object came back changed"
+		check.Err = errors.New(check.Detail)
+		check.Hint = "A proxy or gateway is altering objects"
+		return check
+	}
+	check.Status, check.Detail = CheckPass, "read back "+key
+	return check
+}
+
+func (c *Client) checkDelete(key string) Check {
+	check := Check{Name: "delete"}
+	input := &huaweicloudsdkobs.DeleteObjectInput{Bucket: c.Bucket, Key: key}
+	if _, err := c.client.DeleteObject(input); err != nil {
+		check = permissionFailure(check, "obs:object:DeleteObject", err)
+		check.Hint += "; remove " + key + " by hand"
+		return check
+	}
+	check.Status, check.Detail = CheckPass, "deleted "+key
+	return check
+}
+
+// permissionFailure fails check, hinting at the missing permission when OBS
+// refused the request
+func permissionFailure(check Check, permission string, err error) Check {
+	check.Status, check.Detail, check.Err = CheckFail, err.Error(), err
+	if Classify(err) == ErrorAuth {
+		check.Hint = "Grant " + permission + " to the user of the access key"
+	} else {
+		check.Hint = "Unexpected response; check the endpoint"
+	}
+	return check
+}
+
+func describeStatus(code string, status int) string {
+	if code != "" {
+		return code
+	}
+	return strconv.Itoa(status) + " " + http.StatusText(status)
+}
+
+// probeName returns a unique name for the probe object
+func probeName() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
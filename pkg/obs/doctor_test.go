@@ -0,0 +1,94 @@
+package obs
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkStatuses maps check names to their status
+func checkStatuses(checks []Check) map[string]string {
+	statuses := make(map[string]string)
+	for _, c := range checks {
+		statuses[c.Name] = c.Status
+	}
+	return statuses
+}
+
+func TestDiagnoseHealthy(t *testing.T) {
+	f, client := newFakeServer(t)
+
+	checks := client.Diagnose()
+	want := map[string]string{
+		"dns": CheckPass, "tcp": CheckPass, "tls": CheckSkip, "clock": CheckPass,
+		"credentials": CheckPass, "bucket": CheckPass,
+		"list": CheckPass, "put": CheckPass, "get": CheckPass, "delete": CheckPass,
+	}
+	got := checkStatuses(checks)
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("check %s = %q, want %q (%+v)", name, got[name], status, checks)
+		}
+	}
+
+	// The probe object is cleaned up
+	for _, k := range f.keys() {
+		if strings.HasPrefix(k, DoctorProbePrefix) {
+			t.Errorf("probe object %s left behind", k)
+		}
+	}
+}
+
+func TestDiagnoseFailures(t *testing.T) {
+	t.Run("bad secret key", func(t *testing.T) {
+		f, client := newFakeServer(t)
+		f.rejectAuth = "SignatureDoesNotMatch"
+
+		checks := client.Diagnose()
+		got := checkStatuses(checks)
+		if got["credentials"] != CheckFail || got["bucket"] != CheckSkip || got["put"] != CheckSkip {
+			t.Errorf("unexpected statuses %v", got)
+		}
+		for _, c := range checks {
+			if c.Name == "credentials" {
+				if !strings.Contains(c.Hint, "secret key") {
+					t.Errorf("hint %q does not point at the secret key", c.Hint)
+				}
+				if Classify(c.Err) != ErrorAuth {
+					t.Errorf("credentials failure classified as %s", Classify(c.Err))
+				}
+			}
+		}
+	})
+
+	t.Run("missing bucket", func(t *testing.T) {
+		f, client := newFakeServer(t)
+		f.missing = true
+
+		got := checkStatuses(client.Diagnose())
+		if got["credentials"] != CheckPass || got["bucket"] != CheckFail || got["list"] != CheckSkip {
+			t.Errorf("unexpected statuses %v", got)
+		}
+	})
+
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		client := NewClient("http://127.0.0.1:1", "bucket", "ak", "sk")
+		got := checkStatuses(client.Diagnose())
+		if got["dns"] != CheckPass || got["tcp"] != CheckFail || got["credentials"] != CheckSkip {
+			t.Errorf("unexpected statuses %v", got)
+		}
+	})
+	t.Run("unreachable proxy", func(t *testing.T) {
+		client := NewClient("https://obs.invalid", "bucket", "ak", "sk")
+		client.HTTP = &HTTPOptions{Proxy: "http://127.0.0.1:1"}
+		checks := client.Diagnose()
+		got := checkStatuses(checks)
+		if got["dns"] != CheckSkip || got["tcp"] != CheckFail || got["tls"] != CheckSkip {
+			t.Errorf("unexpected statuses %v", got)
+		}
+		for _, check := range checks {
+			if check.Name == "tcp" && !strings.Contains(check.Hint, "proxy") {
+				t.Errorf("expected a proxy hint, got %q", check.Hint)
+			}
+		}
+	})
+}
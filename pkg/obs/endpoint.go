@@ -0,0 +1,121 @@
+package obs
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Endpoint is a parsed OBS endpoint. An endpoint without a scheme is HTTPS,
+// as in the SDK.
+type Endpoint struct {
+	Scheme string
+	Host   string
+	// Port is the explicit port, or the default port of the scheme
+	Port int
+	// Path is the path after the host; OBS requests go to the host root, so
+	// only an empty path is accepted
+	Path string
+}
+
+// ParseEndpoint parses an endpoint such as obs.cn-north-4.myhuaweicloud.com,
+// https://obs.example.com:8443 or http://127.0.0.1:9000
+func ParseEndpoint(raw string) (Endpoint, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Endpoint{}, fmt.Errorf("endpoint is empty")
+	}
+	withScheme := raw
+	if !strings.Contains(raw, "://") {
+		withScheme = "https://" + raw
+	}
+	u, err := url.Parse(withScheme)
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q: %v", raw, err)
+	}
+
+	e := Endpoint{Scheme: strings.ToLower(u.Scheme), Host: u.Hostname(), Path: strings.TrimRight(u.Path, "/")}
+	if e.Scheme != "http" && e.Scheme != "https" {
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q: scheme must be http or https", raw)
+	}
+	if e.Host == "" {
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q: no host", raw)
+	}
+	if u.RawQuery != "" || u.User != nil {
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q: only scheme, host and port are allowed", raw)
+	}
+	if e.Path != "" {
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q: path %s is not supported, OBS requests go to the host root", raw, e.Path)
+	}
+
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			return Endpoint{}, fmt.Errorf("invalid endpoint %q: bad port %s", raw, p)
+		}
+		e.Port = port
+	} else {
+		e.Port = defaultPort(e.Scheme)
+	}
+	return e, nil
+}
+
+func defaultPort(scheme string) int {
+	if scheme == "http" {
+		return 80
+	}
+	return 443
+}
+
+// Address is host:port to dial
+func (e Endpoint) Address() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// hostPort is the host, with the port only when it is not the scheme default
+func (e Endpoint) hostPort() string {
+	if e.Port == defaultPort(e.Scheme) {
+		if strings.Contains(e.Host, ":") {
+			return "[" + e.Host + "]"
+		}
+		return e.Host
+	}
+	return e.Address()
+}
+
+// URL is the endpoint as the SDK takes it, e.g. http://127.0.0.1:9000
+func (e Endpoint) URL() string {
+	return e.Scheme + "://" + e.hostPort()
+}
+
+// IsPathStyle reports whether buckets must be addressed as a path: virtual
+// hosted names (bucket.host) do not work with IP addresses or localhost
+func (e Endpoint) IsPathStyle() bool {
+	return net.ParseIP(e.Host) != nil || e.Host == "localhost" || strings.HasSuffix(e.Host, ".localhost")
+}
+
+// ObjectURL is the unsigned URL of key in bucket
+func (e Endpoint) ObjectURL(bucket, key string) string {
+	if e.IsPathStyle() {
+		return fmt.Sprintf("%s://%s/%s/%s", e.Scheme, e.hostPort(), bucket, key)
+	}
+	return fmt.Sprintf("%s://%s.%s/%s", e.Scheme, bucket, e.hostPort(), key)
+}
+
+// String returns the endpoint URL
+func (e Endpoint) String() string {
+	return e.URL()
+}
+
+// endpoint parses the client's endpoint. An invalid endpoint is returned
+// as given, so URLs can still be shown; Connect reports the error.
+func (c *Client) endpoint() Endpoint {
+	e, err := ParseEndpoint(c.Endpoint)
+	if err != nil {
+		host := strings.TrimPrefix(strings.TrimPrefix(c.Endpoint, "https://"), "http://")
+		return Endpoint{Scheme: "https", Host: host, Port: 443}
+	}
+	return e
+}
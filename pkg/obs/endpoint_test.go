@@ -0,0 +1,82 @@
+package obs
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Endpoint
+	}{
+		{"obs.cn-north-4.myhuaweicloud.com", Endpoint{Scheme: "https", Host: "obs.cn-north-4.myhuaweicloud.com", Port: 443}},
+		{"https://obs.example.com:8443/", Endpoint{Scheme: "https", Host: "obs.example.com", Port: 8443}},
+		{"http://127.0.0.1:9000", Endpoint{Scheme: "http", Host: "127.0.0.1", Port: 9000}},
+		{"HTTP://localhost", Endpoint{Scheme: "http", Host: "localhost", Port: 80}},
+		{"http://[::1]:9000", Endpoint{Scheme: "http", Host: "::1", Port: 9000}},
+	}
+	for _, tt := range tests {
+		got, err := ParseEndpoint(tt.raw)
+		if err != nil {
+			t.Errorf("ParseEndpoint(%q) failed: %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseEndpoint(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+
+	for _, raw := range []string{"", "ftp://obs.example.com", "https://", "https://obs.example.com/prefix", "obs.example.com:99999", "https://obs.example.com?x=1", "https://user@obs.example.com"} {
+		if _, err := ParseEndpoint(raw); err == nil {
+			t.Errorf("ParseEndpoint(%q) should fail", raw)
+		}
+	}
+}
+
+func TestEndpointURLs(t *testing.T) {
+	tests := []struct {
+		raw, address, url, object string
+	}{
+		{"obs.test.com", "obs.test.com:443", "https://obs.test.com", "https://bucket.obs.test.com/a/b.bin"},
+		{"https://obs.test.com:8443", "obs.test.com:8443", "https://obs.test.com:8443", "https://bucket.obs.test.com:8443/a/b.bin"},
+		{"http://obs.test.com", "obs.test.com:80", "http://obs.test.com", "http://bucket.obs.test.com/a/b.bin"},
+		{"http://127.0.0.1:9000", "127.0.0.1:9000", "http://127.0.0.1:9000", "http://127.0.0.1:9000/bucket/a/b.bin"},
+		{"10.0.0.1", "10.0.0.1:443", "https://10.0.0.1", "https://10.0.0.1/bucket/a/b.bin"},
+		{"http://localhost:9000", "localhost:9000", "http://localhost:9000", "http://localhost:9000/bucket/a/b.bin"},
+	}
+	for _, tt := range tests {
+		e, err := ParseEndpoint(tt.raw)
+		if err != nil {
+			t.Fatalf("ParseEndpoint(%q) failed: %v", tt.raw, err)
+		}
+		if got := e.Address(); got != tt.address {
+			t.Errorf("%s: Address() = %q, want %q", tt.raw, got, tt.address)
+		}
+		if got := e.URL(); got != tt.url {
+			t.Errorf("%s: URL() = %q, want %q", tt.raw, got, tt.url)
+		}
+		if got := e.ObjectURL("bucket", "a/b.bin"); got != tt.object {
+			t.Errorf("%s: ObjectURL() = %q, want %q", tt.raw, got, tt.object)
+		}
+	}
+}
+
+func TestTCPCheckUsesEndpointPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	client := NewClient("http://"+ln.Addr().String(), "bucket", "ak", "sk")
+	if err := client.testTCPConnection(); err != nil {
+		t.Errorf("expected the endpoint port to be dialed, got %v", err)
+	}
+
+	client = NewClient("http://obs.example.com/prefix", "bucket", "ak", "sk")
+	if err := client.testTCPConnection(); err == nil || !strings.Contains(err.Error(), "path") {
+		t.Errorf("expected an invalid endpoint error, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package obs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// ErrorKind classifies a failed operation so callers can report it
+type ErrorKind int
+
+const (
+	// ErrorOther is any failure not classified below
+	ErrorOther ErrorKind = iota
+	// ErrorNetwork means the endpoint could not be reached
+	ErrorNetwork
+	// ErrorAuth means the credentials were rejected or lack permission
+	ErrorAuth
+)
+
+// String returns a short name for the kind
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorNetwork:
+		return "network"
+	case ErrorAuth:
+		return "auth"
+	}
+	return "other"
+}
+
+// authErrorCodes are OBS/S3 error codes caused by credentials
+var authErrorCodes = map[string]bool{
+	"AccessDenied":          true,
+	"InvalidAccessKeyId":    true,
+	"SignatureDoesNotMatch": true,
+	"InvalidSecurity":       true,
+	"InvalidToken":          true,
+	"ExpiredToken":          true,
+	"TokenRefreshRequired":  true,
+}
+
+// tokenErrorCodes are error codes OBS returns for an expired or otherwise
+// unusable security token
+var tokenErrorCodes = map[string]bool{
+	"InvalidToken":         true,
+	"ExpiredToken":         true,
+	"TokenRefreshRequired": true,
+}
+
+// ConnectError reports an endpoint that refused or timed out a connection
+type ConnectError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("cannot connect to OBS endpoint %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the kind of a failure returned by the client
+func Classify(err error) ErrorKind {
+	if err == nil {
+		return ErrorOther
+	}
+
+	var obsErr huaweicloudsdkobs.ObsError
+	if errors.As(err, &obsErr) {
+		if obsErr.StatusCode == 401 || obsErr.StatusCode == 403 || authErrorCodes[obsErr.Code] {
+			return ErrorAuth
+		}
+		return ErrorOther
+	}
+
+	var connErr *ConnectError
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &connErr) || errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return ErrorNetwork
+	}
+	return ErrorOther
+}
+
+// IsNotFound reports whether err is a 404 from OBS, such as a missing key
+// or object version
+func IsNotFound(err error) bool {
+	var obsErr huaweicloudsdkobs.ObsError
+	return errors.As(err, &obsErr) && obsErr.StatusCode == 404
+}
+
+// IsBucketNotEmpty reports whether err is OBS refusing to delete a bucket
+// that still holds objects
+func IsBucketNotEmpty(err error) bool {
+	var obsErr huaweicloudsdkobs.ObsError
+	return errors.As(err, &obsErr) && obsErr.Code == "BucketNotEmpty"
+}
+
+// IsTokenExpired reports whether OBS rejected err's request because its
+// security token expired or is invalid
+func IsTokenExpired(err error) bool {
+	var obsErr huaweicloudsdkobs.ObsError
+	return errors.As(err, &obsErr) && tokenErrorCodes[obsErr.Code]
+}
@@ -0,0 +1,73 @@
+package obs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>")
+	}))
+	defer denied.Close()
+
+	_, err := NewClient(denied.URL, "bucket", "ak", "sk").ListVersionDirs("")
+	if err == nil {
+		t.Fatal("expected listing to fail")
+	}
+	if kind := Classify(err); kind != ErrorAuth {
+		t.Errorf("403 AccessDenied classified as %v, want auth", kind)
+	}
+
+	// Nothing listens on a closed server's address
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	result, err := NewClient(closed.URL, "bucket", "ak", "sk").UploadFile("errors_test.go", "v1.0.0-abc", "", nil)
+	if err != nil {
+		t.Fatalf("UploadFile returned error: %v", err)
+	}
+	if result.Success {
+		t.Fatal("expected upload to fail")
+	}
+	if kind := Classify(result.Err); kind != ErrorNetwork {
+		t.Errorf("unreachable endpoint classified as %v, want network", kind)
+	}
+
+	_, client := newFakeServer(t)
+	client.Bucket = "missing"
+	_, err = client.ListVersionDirs("")
+	if err == nil {
+		t.Fatal("expected listing a missing bucket to fail")
+	}
+	if kind := Classify(err); kind != ErrorOther {
+		t.Errorf("404 NoSuchBucket classified as %v, want other", kind)
+	}
+}
+
+func TestIsTokenExpired(t *testing.T) {
+	expired := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "<Error><Code>ExpiredToken</Code><Message>The provided token has expired.</Message></Error>")
+	}))
+	defer expired.Close()
+
+	client := NewClient(expired.URL, "bucket", "ak", "sk")
+	client.SecurityToken = "token"
+	_, err := client.ListVersionDirs("")
+	if !IsTokenExpired(err) {
+		t.Errorf("expected an expired token error, got %v", err)
+	}
+	if kind := Classify(err); kind != ErrorAuth {
+		t.Errorf("ExpiredToken classified as %v, want auth", kind)
+	}
+
+	_, client = newFakeServer(t)
+	if _, err := client.ListVersionDirs(""); IsTokenExpired(err) {
+		t.Errorf("unexpected expired token error: %v", err)
+	}
+}
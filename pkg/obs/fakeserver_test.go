@@ -0,0 +1,563 @@
+package obs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeObject is an object stored by fakeServer
+type fakeObject struct {
+	data     []byte
+	modified time.Time
+	metadata map[string]string
+	// versionID is set for objects written while versioning was enabled
+	versionID string
+}
+
+// fakeServer is a minimal path-style S3/OBS endpoint holding one bucket.
+// It supports listing (prefix, marker, max-keys, delimiter), multi-object
+// delete, server-side copy, bucket create/delete, lifecycle, versioning and
+// multipart upload listing/abort, and object GET/HEAD/PUT/DELETE, which is
+// enough to exercise the client end to end.
+type fakeServer struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]*fakeObject
+	// pageSize caps list responses to force pagination; 0 means 1000
+	pageSize int
+	// listCalls counts bucket listing requests
+	listCalls int
+	// deleteCalls counts multi-object delete requests
+	deleteCalls int
+	// denyDelete makes deletes of these keys fail with AccessDenied
+	denyDelete map[string]bool
+	// lifecycle is the stored lifecycle configuration XML, nil when unset
+	lifecycle []byte
+	// versioning is the bucket versioning status, "" when never set
+	versioning string
+	// history holds the replaced copies of each key, oldest first
+	history map[string][]*fakeObject
+	// versionSeq numbers the version ids handed out
+	versionSeq int
+	// uploads maps the ids of incomplete multipart uploads to their keys
+	uploads map[string]string
+	// missing is set while the bucket does not exist
+	missing bool
+	// createHeaders are the headers of the last bucket creation
+	createHeaders http.Header
+	// createBody is the body of the last bucket creation
+	createBody []byte
+	// rejectAuth, when set, fails every request with this error code
+	rejectAuth string
+	// lastAuth and lastToken are the Authorization and security token
+	// headers of the last request
+	lastAuth, lastToken string
+}
+
+// newFakeServer starts a fake endpoint and returns a client connected to it
+func newFakeServer(t *testing.T) (*fakeServer, *Client) {
+	t.Helper()
+	f := &fakeServer{bucket: "bucket", objects: make(map[string]*fakeObject), history: make(map[string][]*fakeObject), uploads: make(map[string]string)}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, NewClient(server.URL, f.bucket, "ak", "sk")
+}
+
+// put stores an object of the given size, keeping the replaced copy when
+// versioning is enabled
+func (f *fakeServer) put(key string, size int, modified time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(key, &fakeObject{data: make([]byte, size), modified: modified})
+}
+
+// store writes obj under key and returns its version id, "" when unversioned
+func (f *fakeServer) store(key string, obj *fakeObject) string {
+	if f.versioning == "Enabled" {
+		if old, ok := f.objects[key]; ok {
+			f.history[key] = append(f.history[key], old)
+		}
+		f.versionSeq++
+		obj.versionID = fmt.Sprintf("v%04d", f.versionSeq)
+	}
+	f.objects[key] = obj
+	return obj.versionID
+}
+
+// keys returns the stored keys in order
+func (f *fakeServer) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastAuth = r.Header.Get("Authorization")
+	f.lastToken = r.Header.Get("x-amz-security-token") + r.Header.Get("x-obs-security-token")
+	if f.rejectAuth != "" {
+		f.writeError(w, http.StatusForbidden, f.rejectAuth)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket == "" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, "<ListAllMyBucketsResult><Owner><ID>owner</ID></Owner><Buckets><Bucket><Name>%s</Name></Bucket></Buckets></ListAllMyBucketsResult>", f.bucket)
+		return
+	}
+	if bucket != f.bucket {
+		f.writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if key == "" && r.Method == http.MethodPut && len(r.URL.Query()) == 0 {
+		f.createBucket(w, r)
+		return
+	}
+	if f.missing {
+		f.writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if key == "" {
+		if r.URL.Query().Has("lifecycle") {
+			f.bucketLifecycle(w, r)
+			return
+		}
+		if r.URL.Query().Has("versioning") {
+			f.bucketVersioning(w, r)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Query().Has("versions") {
+			f.listVersions(w, r)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Query().Has("uploads") {
+			f.listUploads(w)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			f.deleteBucket(w)
+			return
+		}
+		if r.Method == http.MethodGet {
+			f.list(w, r)
+			return
+		}
+		if r.Method == http.MethodPost && r.URL.Query().Has("delete") {
+			f.deleteObjects(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Subresources such as ?acl are accepted without touching the object
+	if r.URL.Query().Has("acl") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if versionID := r.URL.Query().Get("versionId"); versionID != "" {
+			obj, ok = f.findVersion(key, versionID)
+		}
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if obj.versionID != "" {
+			w.Header().Set("x-amz-version-id", obj.versionID)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modified.UTC().Format(http.TimeFormat))
+		for k, v := range obj.metadata {
+			w.Header().Set("x-amz-meta-"+k, v)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodPut:
+		if source := copySource(r); source != "" {
+			f.copyObject(w, source, key)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		metadata := make(map[string]string)
+		for k := range r.Header {
+			lower := strings.ToLower(k)
+			if strings.HasPrefix(lower, "x-amz-meta-") {
+				metadata[strings.TrimPrefix(lower, "x-amz-meta-")] = r.Header.Get(k)
+			}
+		}
+		versionID := f.store(key, &fakeObject{data: data, modified: time.Now(), metadata: metadata})
+		if versionID != "" {
+			w.Header().Set("x-amz-version-id", versionID)
+		}
+		w.Header().Set("ETag", "\"etag\"")
+	case http.MethodDelete:
+		if uploadID := r.URL.Query().Get("uploadId"); uploadID != "" {
+			if f.uploads[uploadID] != key {
+				f.writeError(w, http.StatusNotFound, "NoSuchUpload")
+				return
+			}
+			delete(f.uploads, uploadID)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if f.denyDelete[key] {
+			f.writeError(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+type fakeListResult struct {
+	XMLName        xml.Name         `xml:"ListBucketResult"`
+	Name           string           `xml:"Name"`
+	Prefix         string           `xml:"Prefix"`
+	Marker         string           `xml:"Marker"`
+	NextMarker     string           `xml:"NextMarker,omitempty"`
+	MaxKeys        int              `xml:"MaxKeys"`
+	Delimiter      string           `xml:"Delimiter,omitempty"`
+	IsTruncated    bool             `xml:"IsTruncated"`
+	Contents       []fakeListObject `xml:"Contents"`
+	CommonPrefixes []string         `xml:"CommonPrefixes>Prefix"`
+}
+
+type fakeListObject struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+}
+
+func (f *fakeServer) list(w http.ResponseWriter, r *http.Request) {
+	f.listCalls++
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	marker := query.Get("marker")
+	delimiter := query.Get("delimiter")
+	maxKeys := 1000
+	if n, err := strconv.Atoi(query.Get("max-keys")); err == nil && n > 0 {
+		maxKeys = n
+	}
+	if f.pageSize > 0 && f.pageSize < maxKeys {
+		maxKeys = f.pageSize
+	}
+
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := fakeListResult{Name: f.bucket, Prefix: prefix, Marker: marker, MaxKeys: maxKeys, Delimiter: delimiter}
+	seenPrefixes := make(map[string]bool)
+	count := 0
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) || k <= marker {
+			continue
+		}
+		entry := k
+		isPrefix := false
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				entry = k[:len(prefix)+i+len(delimiter)]
+				isPrefix = true
+			}
+		}
+		if isPrefix && (seenPrefixes[entry] || entry <= marker) {
+			continue
+		}
+		if count == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		count++
+		result.NextMarker = entry
+		if isPrefix {
+			seenPrefixes[entry] = true
+			result.CommonPrefixes = append(result.CommonPrefixes, entry)
+			continue
+		}
+		obj := f.objects[k]
+		result.Contents = append(result.Contents, fakeListObject{
+			Key:          k,
+			LastModified: obj.modified.UTC().Format("2006-01-02T15:04:05.000Z"),
+			ETag:         "\"etag\"",
+			Size:         len(obj.data),
+		})
+	}
+	if !result.IsTruncated {
+		result.NextMarker = ""
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// bucketLifecycle stores, returns and removes the lifecycle configuration
+func (f *fakeServer) bucketLifecycle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		f.lifecycle, _ = io.ReadAll(r.Body)
+	case http.MethodGet:
+		if f.lifecycle == nil {
+			f.writeError(w, http.StatusNotFound, "NoSuchLifecycleConfiguration")
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(f.lifecycle)
+	case http.MethodDelete:
+		f.lifecycle = nil
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// bucketVersioning stores and returns the versioning status
+func (f *fakeServer) bucketVersioning(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		var config struct {
+			Status string `xml:"Status"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&config); err != nil {
+			f.writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		f.versioning = config.Status
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, "<VersioningConfiguration><Status>%s</Status></VersioningConfiguration>", f.versioning)
+	}
+}
+
+// findVersion returns the current or a replaced copy of key by version id
+func (f *fakeServer) findVersion(key, versionID string) (*fakeObject, bool) {
+	if obj, ok := f.objects[key]; ok && obj.versionID == versionID {
+		return obj, true
+	}
+	for _, obj := range f.history[key] {
+		if obj.versionID == versionID {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+type fakeVersionsResult struct {
+	XMLName     xml.Name          `xml:"ListVersionsResult"`
+	Name        string            `xml:"Name"`
+	Prefix      string            `xml:"Prefix"`
+	IsTruncated bool              `xml:"IsTruncated"`
+	Versions    []fakeVersionItem `xml:"Version"`
+}
+
+type fakeVersionItem struct {
+	Key          string `xml:"Key"`
+	VersionId    string `xml:"VersionId"`
+	IsLatest     bool   `xml:"IsLatest"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+}
+
+// listVersions handles GET /bucket?versions in one page, without delete markers
+func (f *fakeServer) listVersions(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	result := fakeVersionsResult{Name: f.bucket, Prefix: prefix}
+	add := func(key string, obj *fakeObject, latest bool) {
+		versionID := obj.versionID
+		if versionID == "" {
+			versionID = "null"
+		}
+		result.Versions = append(result.Versions, fakeVersionItem{
+			Key:          key,
+			VersionId:    versionID,
+			IsLatest:     latest,
+			LastModified: obj.modified.UTC().Format("2006-01-02T15:04:05.000Z"),
+			ETag:         "\"etag\"",
+			Size:         len(obj.data),
+		})
+	}
+	for key, obj := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			add(key, obj, true)
+		}
+	}
+	for key, objs := range f.history {
+		if strings.HasPrefix(key, prefix) {
+			for _, obj := range objs {
+				add(key, obj, false)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// copySource returns the source key of a copy request, or ""
+func copySource(r *http.Request) string {
+	source := r.Header.Get("x-amz-copy-source")
+	if source == "" {
+		source = r.Header.Get("x-obs-copy-source")
+	}
+	if source == "" {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(source); err == nil {
+		source = unescaped
+	}
+	_, key, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	return key
+}
+
+// copyObject handles PUT with a copy source, keeping data and metadata
+func (f *fakeServer) copyObject(w http.ResponseWriter, from, to string) {
+	obj, ok := f.objects[from]
+	if !ok {
+		f.writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	copied := *obj
+	copied.modified = time.Now()
+	f.objects[to] = &copied
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprintf(w, "<CopyObjectResult><LastModified>%s</LastModified><ETag>\"etag\"</ETag></CopyObjectResult>", copied.modified.UTC().Format(time.RFC3339))
+}
+
+type fakeDeleteRequest struct {
+	Objects []struct {
+		Key       string `xml:"Key"`
+		VersionId string `xml:"VersionId"`
+	} `xml:"Object"`
+}
+
+type fakeDeleteResult struct {
+	XMLName xml.Name `xml:"DeleteResult"`
+	Deleted []struct {
+		Key string `xml:"Key"`
+	} `xml:"Deleted"`
+	Errors []fakeDeleteError `xml:"Error"`
+}
+
+type fakeDeleteError struct {
+	Key     string `xml:"Key"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// deleteObjects handles POST /bucket?delete, honouring denyDelete per key
+func (f *fakeServer) deleteObjects(w http.ResponseWriter, r *http.Request) {
+	f.deleteCalls++
+	var req fakeDeleteRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	var result fakeDeleteResult
+	for _, obj := range req.Objects {
+		if f.denyDelete[obj.Key] {
+			result.Errors = append(result.Errors, fakeDeleteError{Key: obj.Key, Code: "AccessDenied", Message: "Access Denied"})
+			continue
+		}
+		f.deleteVersion(obj.Key, obj.VersionId)
+		result.Deleted = append(result.Deleted, struct {
+			Key string `xml:"Key"`
+		}{obj.Key})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// deleteVersion removes one replaced copy of key by version id, or else the
+// current object
+func (f *fakeServer) deleteVersion(key, versionID string) {
+	for i, obj := range f.history[key] {
+		if versionID != "" && obj.versionID == versionID {
+			f.history[key] = append(f.history[key][:i], f.history[key][i+1:]...)
+			if len(f.history[key]) == 0 {
+				delete(f.history, key)
+			}
+			return
+		}
+	}
+	delete(f.objects, key)
+}
+
+// createBucket handles PUT /bucket, recording the request
+func (f *fakeServer) createBucket(w http.ResponseWriter, r *http.Request) {
+	if !f.missing {
+		f.writeError(w, http.StatusConflict, "BucketAlreadyOwnedByYou")
+		return
+	}
+	f.missing = false
+	f.createHeaders = r.Header.Clone()
+	f.createBody, _ = io.ReadAll(r.Body)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteBucket handles DELETE /bucket, which must be empty
+func (f *fakeServer) deleteBucket(w http.ResponseWriter) {
+	if len(f.objects) > 0 || len(f.history) > 0 || len(f.uploads) > 0 {
+		f.writeError(w, http.StatusConflict, "BucketNotEmpty")
+		return
+	}
+	f.missing = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type fakeUploadsResult struct {
+	XMLName xml.Name `xml:"ListMultipartUploadsResult"`
+	Bucket  string   `xml:"Bucket"`
+	Uploads []struct {
+		Key      string `xml:"Key"`
+		UploadId string `xml:"UploadId"`
+	} `xml:"Upload"`
+}
+
+// listUploads handles GET /bucket?uploads in one page
+func (f *fakeServer) listUploads(w http.ResponseWriter) {
+	result := fakeUploadsResult{Bucket: f.bucket}
+	for id, key := range f.uploads {
+		result.Uploads = append(result.Uploads, struct {
+			Key      string `xml:"Key"`
+			UploadId string `xml:"UploadId"`
+		}{key, id})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func (f *fakeServer) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+}
@@ -0,0 +1,276 @@
+package obs
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Defaults of HTTPOptions; the timeouts and connection limit match the SDK
+const (
+	DefaultConnectTimeout  = 60 * time.Second
+	DefaultSocketTimeout   = 60 * time.Second
+	DefaultMaxIdleConns    = 1000
+	DefaultTCPCheckTimeout = 3 * time.Second
+
+	idleConnTimeout = 30 * time.Second
+)
+
+// ProxyDirect as the proxy disables proxies, including those from the
+// environment
+const ProxyDirect = "direct"
+
+// HTTPOptions are the per-profile HTTP settings:
+//
+//	http:
+//	  proxy: http://proxy.corp.example:3128
+//	  no_proxy: .corp.example,10.0.0.0/8
+//	  connect_timeout: 10s
+//	  socket_timeout: 2m
+//	  timeout: 30m
+//	  max_idle_conns: 100
+//	  tcp_check_timeout: 10s
+//
+// Zero values use the defaults.
+type HTTPOptions struct {
+	// Proxy is the proxy URL for all requests, or "direct" for none; by
+	// default HTTPS_PROXY and HTTP_PROXY are used
+	Proxy string `yaml:"proxy,omitempty"`
+	// NoProxy lists hosts reached without the proxy, as in NO_PROXY, which
+	// is used by default
+	NoProxy string `yaml:"no_proxy,omitempty"`
+	// ConnectTimeout limits establishing a connection
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty"`
+	// SocketTimeout limits each read and write, and the wait for a response
+	SocketTimeout time.Duration `yaml:"socket_timeout,omitempty"`
+	// Timeout limits a whole request, body included; no limit by default.
+	// Each file is uploaded in one request, so it must cover the largest.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// MaxIdleConns is the number of connections kept open for reuse
+	MaxIdleConns int `yaml:"max_idle_conns,omitempty"`
+	// TCPCheckTimeout limits the connection test run before each operation
+	TCPCheckTimeout time.Duration `yaml:"tcp_check_timeout,omitempty"`
+}
+
+// Validate checks the proxy URL and that no setting is negative
+func (o *HTTPOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Proxy != "" && o.Proxy != ProxyDirect {
+		u, err := url.Parse(o.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy %q: %v", o.Proxy, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", o.Proxy)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid proxy %q: no host", o.Proxy)
+		}
+	}
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"connect_timeout", o.ConnectTimeout},
+		{"socket_timeout", o.SocketTimeout},
+		{"timeout", o.Timeout},
+		{"tcp_check_timeout", o.TCPCheckTimeout},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			return fmt.Errorf("%s must not be negative", d.name)
+		}
+	}
+	if o.MaxIdleConns < 0 {
+		return fmt.Errorf("max_idle_conns must not be negative")
+	}
+	return nil
+}
+
+// String describes the options that are set, e.g. "proxy=http://p:3128, timeout=30m0s"
+func (o *HTTPOptions) String() string {
+	if o == nil {
+		return ""
+	}
+	var parts []string
+	add := func(name string, set bool, value interface{}) {
+		if set {
+			parts = append(parts, fmt.Sprintf("%s=%v", name, value))
+		}
+	}
+	add("proxy", o.Proxy != "", redactProxy(o.Proxy))
+	add("no_proxy", o.NoProxy != "", o.NoProxy)
+	add("connect_timeout", o.ConnectTimeout > 0, o.ConnectTimeout)
+	add("socket_timeout", o.SocketTimeout > 0, o.SocketTimeout)
+	add("timeout", o.Timeout > 0, o.Timeout)
+	add("max_idle_conns", o.MaxIdleConns > 0, o.MaxIdleConns)
+	add("tcp_check_timeout", o.TCPCheckTimeout > 0, o.TCPCheckTimeout)
+	return strings.Join(parts, ", ")
+}
+
+// redactProxy hides the password of a proxy URL
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy
+	}
+	return u.Redacted()
+}
+
+func (o *HTTPOptions) connectTimeout() time.Duration {
+	if o == nil || o.ConnectTimeout == 0 {
+		return DefaultConnectTimeout
+	}
+	return o.ConnectTimeout
+}
+
+func (o *HTTPOptions) socketTimeout() time.Duration {
+	if o == nil || o.SocketTimeout == 0 {
+		return DefaultSocketTimeout
+	}
+	return o.SocketTimeout
+}
+
+func (o *HTTPOptions) timeout() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.Timeout
+}
+
+func (o *HTTPOptions) maxIdleConns() int {
+	if o == nil || o.MaxIdleConns == 0 {
+		return DefaultMaxIdleConns
+	}
+	return o.MaxIdleConns
+}
+
+func (o *HTTPOptions) tcpCheckTimeout() time.Duration {
+	if o == nil || o.TCPCheckTimeout == 0 {
+		return DefaultTCPCheckTimeout
+	}
+	return o.TCPCheckTimeout
+}
+
+// proxyFunc picks the proxy of a request URL. The environment is read on
+// every call rather than once per process as by http.ProxyFromEnvironment.
+func (o *HTTPOptions) proxyFunc() func(*url.URL) (*url.URL, error) {
+	if o != nil && o.Proxy == ProxyDirect {
+		return func(*url.URL) (*url.URL, error) { return nil, nil }
+	}
+	cfg := httpproxy.FromEnvironment()
+	if o != nil && o.Proxy != "" {
+		cfg.HTTPProxy, cfg.HTTPSProxy = o.Proxy, o.Proxy
+	}
+	if o != nil && o.NoProxy != "" {
+		cfg.NoProxy = o.NoProxy
+	}
+	return cfg.ProxyFunc()
+}
+
+// proxyFor returns the proxy used for the endpoint, or nil for none
+func (o *HTTPOptions) proxyFor(endpoint Endpoint) (*url.URL, error) {
+	u, err := url.Parse(endpoint.URL())
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := o.proxyFunc()(u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %v", err)
+	}
+	return proxy, nil
+}
+
+// dialAddress is the address the connection test dials: the proxy when the
+// endpoint is reached through one, the endpoint otherwise
+func (o *HTTPOptions) dialAddress(endpoint Endpoint) (string, error) {
+	proxy, err := o.proxyFor(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if proxy == nil {
+		return endpoint.Address(), nil
+	}
+	port := proxy.Port()
+	if port == "" {
+		switch proxy.Scheme {
+		case "https":
+			port = "443"
+		case "socks5":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(proxy.Hostname(), port), nil
+}
+
+// httpTransport builds the transport of the profile's TLS and HTTP settings
+func (c *Client) httpTransport(endpoint Endpoint) (*http.Transport, error) {
+	tlsConfig, err := c.TLS.Config(endpoint.Host)
+	if err != nil {
+		return nil, err
+	}
+	proxy := c.HTTP.proxyFunc()
+	dialer := &net.Dialer{Timeout: c.HTTP.connectTimeout()}
+	socketTimeout := c.HTTP.socketTimeout()
+	return &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) { return proxy(req.URL) },
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &deadlineConn{Conn: conn, timeout: socketTimeout}, nil
+		},
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConns:          c.HTTP.maxIdleConns(),
+		MaxIdleConnsPerHost:   c.HTTP.maxIdleConns(),
+		ResponseHeaderTimeout: socketTimeout,
+		IdleConnTimeout:       idleConnTimeout,
+		// As in the SDK: bodies are passed on exactly as stored
+		DisableCompression: true,
+	}, nil
+}
+
+// httpClient is the client the SDK sends requests with. Redirects are
+// returned to the SDK, which handles them itself.
+func (c *Client) httpClient(transport *http.Transport) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		Timeout:   c.HTTP.timeout(),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// deadlineConn fails a read or write that makes no progress within timeout.
+// A write also extends the read deadline: the transport waits for the
+// response while a large body is still being sent.
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Read(b)
+}
+
+func (c *deadlineConn) Write(b []byte) (int, error) {
+	c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	n, err := c.Conn.Write(b)
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return n, err
+}
@@ -0,0 +1,231 @@
+package obs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// LifecycleRule is a bucket lifecycle rule as written in obsput rule files:
+//
+//	rules:
+//	  - id: nightly
+//	    prefix: nightly/
+//	    warm_days: 7
+//	    cold_days: 30
+//	    expire_days: 90
+//	    abort_multipart_days: 3
+type LifecycleRule struct {
+	ID string `yaml:"id"`
+	// Prefix selects the objects the rule applies to; empty means the whole bucket
+	Prefix string `yaml:"prefix"`
+	// Disabled keeps the rule on the bucket without applying it
+	Disabled bool `yaml:"disabled,omitempty"`
+	// WarmDays and ColdDays move objects to the WARM and COLD storage classes
+	WarmDays int `yaml:"warm_days,omitempty"`
+	ColdDays int `yaml:"cold_days,omitempty"`
+	// ExpireDays deletes objects this many days after upload
+	ExpireDays int `yaml:"expire_days,omitempty"`
+	// AbortMultipartDays aborts multipart uploads left incomplete this long
+	AbortMultipartDays int `yaml:"abort_multipart_days,omitempty"`
+}
+
+// Validate checks a rule the way OBS would, so mistakes show up before the
+// whole configuration is rejected
+func (r LifecycleRule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("lifecycle rule for prefix %q has no id", r.Prefix)
+	}
+	if r.WarmDays < 0 || r.ColdDays < 0 || r.ExpireDays < 0 || r.AbortMultipartDays < 0 {
+		return fmt.Errorf("lifecycle rule %s: days must not be negative", r.ID)
+	}
+	if r.WarmDays == 0 && r.ColdDays == 0 && r.ExpireDays == 0 && r.AbortMultipartDays == 0 {
+		return fmt.Errorf("lifecycle rule %s: no action (warm_days, cold_days, expire_days or abort_multipart_days)", r.ID)
+	}
+	if r.WarmDays > 0 && r.ColdDays > 0 && r.ColdDays <= r.WarmDays {
+		return fmt.Errorf("lifecycle rule %s: cold_days must be after warm_days", r.ID)
+	}
+	for _, days := range []int{r.WarmDays, r.ColdDays} {
+		if days > 0 && r.ExpireDays > 0 && r.ExpireDays <= days {
+			return fmt.Errorf("lifecycle rule %s: expire_days must be after the transitions", r.ID)
+		}
+	}
+	return nil
+}
+
+// String describes the rule, e.g. "nightly/: warm 7d, cold 30d, expire 90d"
+func (r LifecycleRule) String() string {
+	var actions []string
+	if r.WarmDays > 0 {
+		actions = append(actions, fmt.Sprintf("warm %dd", r.WarmDays))
+	}
+	if r.ColdDays > 0 {
+		actions = append(actions, fmt.Sprintf("cold %dd", r.ColdDays))
+	}
+	if r.ExpireDays > 0 {
+		actions = append(actions, fmt.Sprintf("expire %dd", r.ExpireDays))
+	}
+	if r.AbortMultipartDays > 0 {
+		actions = append(actions, fmt.Sprintf("abort multipart %dd", r.AbortMultipartDays))
+	}
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = "(all)"
+	}
+	s := fmt.Sprintf("%s %s: %s", r.ID, prefix, strings.Join(actions, ", "))
+	if r.Disabled {
+		s += " (disabled)"
+	}
+	return s
+}
+
+// ValidateLifecycleRules checks each rule and that ids are unique
+func ValidateLifecycleRules(rules []LifecycleRule) error {
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("duplicate lifecycle rule id %s", r.ID)
+		}
+		seen[r.ID] = true
+	}
+	return nil
+}
+
+// GetLifecycle returns the bucket's lifecycle rules; a bucket without a
+// lifecycle configuration has none
+func (c *Client) GetLifecycle() ([]LifecycleRule, error) {
+	sdkRules, err := c.sdkLifecycleRules()
+	if err != nil {
+		return nil, err
+	}
+	return lifecycleRulesFromSDK(sdkRules), nil
+}
+
+// SetLifecycle replaces the bucket's lifecycle rules
+func (c *Client) SetLifecycle(rules []LifecycleRule) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("no lifecycle rules to set")
+	}
+	if err := ValidateLifecycleRules(rules); err != nil {
+		return err
+	}
+
+	if err := c.ready(); err != nil {
+		return err
+	}
+
+	input := &huaweicloudsdkobs.SetBucketLifecycleConfigurationInput{Bucket: c.Bucket}
+	for _, r := range rules {
+		input.LifecycleRules = append(input.LifecycleRules, r.sdk())
+	}
+	_, err := c.client.SetBucketLifecycleConfiguration(input)
+	return err
+}
+
+// SetLifecycleRule adds rule to the bucket, or replaces the rule with the
+// same id, and returns the resulting rules. The other rules are written back
+// as OBS returned them, so settings LifecycleRule cannot hold, such as
+// expiration dates, noncurrent version actions or tag filters, survive.
+func (c *Client) SetLifecycleRule(rule LifecycleRule) ([]LifecycleRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	sdkRules, err := c.sdkLifecycleRules()
+	if err != nil {
+		return nil, err
+	}
+	replaced := false
+	for i, r := range sdkRules {
+		if r.ID == rule.ID {
+			sdkRules[i], replaced = rule.sdk(), true
+		}
+	}
+	if !replaced {
+		sdkRules = append(sdkRules, rule.sdk())
+	}
+
+	input := &huaweicloudsdkobs.SetBucketLifecycleConfigurationInput{Bucket: c.Bucket}
+	input.LifecycleRules = sdkRules
+	if _, err := c.client.SetBucketLifecycleConfiguration(input); err != nil {
+		return nil, err
+	}
+	return lifecycleRulesFromSDK(sdkRules), nil
+}
+
+// sdkLifecycleRules returns the bucket's lifecycle rules as OBS returns them
+func (c *Client) sdkLifecycleRules() ([]huaweicloudsdkobs.LifecycleRule, error) {
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+
+	output, err := c.client.GetBucketLifecycleConfiguration(c.Bucket)
+	if err != nil {
+		var obsErr huaweicloudsdkobs.ObsError
+		if errors.As(err, &obsErr) && obsErr.Code == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return output.LifecycleRules, nil
+}
+
+// lifecycleRulesFromSDK converts OBS rules, keeping what LifecycleRule can hold
+func lifecycleRulesFromSDK(sdkRules []huaweicloudsdkobs.LifecycleRule) []LifecycleRule {
+	rules := make([]LifecycleRule, 0, len(sdkRules))
+	for _, sdkRule := range sdkRules {
+		rule := LifecycleRule{
+			ID:                 sdkRule.ID,
+			Prefix:             sdkRule.Prefix,
+			Disabled:           sdkRule.Status == huaweicloudsdkobs.RuleStatusDisabled,
+			ExpireDays:         sdkRule.Expiration.Days,
+			AbortMultipartDays: sdkRule.AbortIncompleteMultipartUpload.DaysAfterInitiation,
+		}
+		for _, t := range sdkRule.Transitions {
+			switch strings.ToUpper(string(t.StorageClass)) {
+			case "WARM", "STANDARD_IA":
+				rule.WarmDays = t.Days
+			case "COLD", "GLACIER":
+				rule.ColdDays = t.Days
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// sdk converts the rule for OBS
+func (r LifecycleRule) sdk() huaweicloudsdkobs.LifecycleRule {
+	sdkRule := huaweicloudsdkobs.LifecycleRule{
+		ID:     r.ID,
+		Prefix: r.Prefix,
+		Status: huaweicloudsdkobs.RuleStatusEnabled,
+	}
+	if r.Disabled {
+		sdkRule.Status = huaweicloudsdkobs.RuleStatusDisabled
+	}
+	if r.WarmDays > 0 {
+		sdkRule.Transitions = append(sdkRule.Transitions, huaweicloudsdkobs.Transition{Days: r.WarmDays, StorageClass: huaweicloudsdkobs.StorageClassWarm})
+	}
+	if r.ColdDays > 0 {
+		sdkRule.Transitions = append(sdkRule.Transitions, huaweicloudsdkobs.Transition{Days: r.ColdDays, StorageClass: huaweicloudsdkobs.StorageClassCold})
+	}
+	sdkRule.Expiration.Days = r.ExpireDays
+	sdkRule.AbortIncompleteMultipartUpload.DaysAfterInitiation = r.AbortMultipartDays
+	return sdkRule
+}
+
+// DeleteLifecycle removes every lifecycle rule from the bucket
+func (c *Client) DeleteLifecycle() error {
+	if err := c.ready(); err != nil {
+		return err
+	}
+
+	_, err := c.client.DeleteBucketLifecycleConfiguration(c.Bucket)
+	return err
+}
@@ -0,0 +1,114 @@
+package obs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLifecycleRuleValidate(t *testing.T) {
+	tests := []struct {
+		rule LifecycleRule
+		ok   bool
+	}{
+		{LifecycleRule{ID: "nightly", Prefix: "nightly/", ExpireDays: 30}, true},
+		{LifecycleRule{ID: "tiers", WarmDays: 30, ColdDays: 60, ExpireDays: 90}, true},
+		{LifecycleRule{ID: "abort", AbortMultipartDays: 7}, true},
+		{LifecycleRule{Prefix: "nightly/", ExpireDays: 30}, false},
+		{LifecycleRule{ID: "empty", Prefix: "nightly/"}, false},
+		{LifecycleRule{ID: "negative", ExpireDays: -1}, false},
+		{LifecycleRule{ID: "order", WarmDays: 60, ColdDays: 30}, false},
+		{LifecycleRule{ID: "expire-early", ColdDays: 60, ExpireDays: 30}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.rule.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.rule, err, tt.ok)
+		}
+	}
+
+	dup := []LifecycleRule{{ID: "a", ExpireDays: 1}, {ID: "a", ExpireDays: 2}}
+	if err := ValidateLifecycleRules(dup); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("expected duplicate id error, got %v", err)
+	}
+}
+
+func TestLifecycleRoundTrip(t *testing.T) {
+	_, client := newFakeServer(t)
+
+	rules, err := client.GetLifecycle()
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("expected no rules on a new bucket, got %v, %v", rules, err)
+	}
+
+	want := []LifecycleRule{
+		{ID: "nightly", Prefix: "nightly/", WarmDays: 7, ColdDays: 30, ExpireDays: 90},
+		{ID: "uploads", AbortMultipartDays: 3, Disabled: true},
+	}
+	if err := client.SetLifecycle(want); err != nil {
+		t.Fatalf("SetLifecycle failed: %v", err)
+	}
+	got, err := client.GetLifecycle()
+	if err != nil {
+		t.Fatalf("GetLifecycle failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetLifecycle = %+v, want %+v", got, want)
+	}
+
+	if err := client.DeleteLifecycle(); err != nil {
+		t.Fatalf("DeleteLifecycle failed: %v", err)
+	}
+	if rules, err := client.GetLifecycle(); err != nil || len(rules) != 0 {
+		t.Errorf("expected rules to be removed, got %v, %v", rules, err)
+	}
+
+	if err := client.SetLifecycle([]LifecycleRule{{ID: "bad"}}); err == nil {
+		t.Error("expected an invalid rule to be rejected before sending")
+	}
+}
+
+func TestSetLifecycleRuleKeepsOtherRules(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.lifecycle = []byte(`<LifecycleConfiguration>` +
+		`<Rule><ID>old-versions</ID><Prefix>releases/</Prefix><Status>Enabled</Status>` +
+		`<NoncurrentVersionExpiration><NoncurrentDays>30</NoncurrentDays></NoncurrentVersionExpiration></Rule>` +
+		`<Rule><ID>nightly</ID><Prefix>nightly/</Prefix><Status>Enabled</Status>` +
+		`<Expiration><Days>7</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`)
+
+	rules, err := client.SetLifecycleRule(LifecycleRule{ID: "nightly", Prefix: "nightly/", ExpireDays: 30})
+	if err != nil {
+		t.Fatalf("SetLifecycleRule failed: %v", err)
+	}
+	if len(rules) != 2 || rules[1].ExpireDays != 30 {
+		t.Errorf("expected nightly to be replaced in place, got %+v", rules)
+	}
+
+	sdkRules, err := client.sdkLifecycleRules()
+	if err != nil {
+		t.Fatalf("reading the rules back failed: %v", err)
+	}
+	if len(sdkRules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", sdkRules)
+	}
+	if sdkRules[0].ID != "old-versions" || sdkRules[0].NoncurrentVersionExpiration.NoncurrentDays != 30 {
+		t.Errorf("noncurrent version expiration was not kept: %+v", sdkRules[0])
+	}
+	if sdkRules[1].Expiration.Days != 30 {
+		t.Errorf("nightly expires after %d days, want 30", sdkRules[1].Expiration.Days)
+	}
+
+	if _, err := client.SetLifecycleRule(LifecycleRule{ID: "uploads", AbortMultipartDays: 3}); err != nil {
+		t.Fatalf("SetLifecycleRule failed: %v", err)
+	}
+	if sdkRules, _ := client.sdkLifecycleRules(); len(sdkRules) != 3 || sdkRules[2].ID != "uploads" {
+		t.Errorf("expected uploads to be appended, got %+v", sdkRules)
+	}
+}
+
+func TestLifecycleRuleString(t *testing.T) {
+	r := LifecycleRule{ID: "nightly", Prefix: "nightly/", ColdDays: 30, ExpireDays: 90, Disabled: true}
+	if got := r.String(); got != "nightly nightly/: cold 30d, expire 90d (disabled)" {
+		t.Errorf("unexpected String: %q", got)
+	}
+}
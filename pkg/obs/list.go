@@ -0,0 +1,596 @@
+package obs
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"obsput/pkg/platform"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// Sort orders accepted by ListOptions.Sort
+const (
+	SortVersion = "version"
+	SortDate    = "date"
+	SortSize    = "size"
+)
+
+// ListOptions filters, orders and limits ListVersionsWithOptions and
+// ListVersionGroups results
+type ListOptions struct {
+	// Prefix is the upload prefix ({prefix} in the key template). With a
+	// template that has no {prefix} it is used as a raw key prefix.
+	Prefix string
+	// Since and Until bound the object modification time; zero means unbounded
+	Since time.Time
+	Until time.Time
+	// Commit matches the start of the commit in the version
+	Commit string
+	// Branch matches {branch} in the key template
+	Branch string
+	// Name is a glob matched against the filename
+	Name string
+	// Limit caps the number of results; 0 means no limit
+	Limit int
+	// Sort is one of SortVersion, SortDate, SortSize, or empty for key order
+	Sort    string
+	Reverse bool
+}
+
+// ValidateSort checks a sort order name
+func ValidateSort(order string) error {
+	switch order {
+	case "", SortVersion, SortDate, SortSize:
+		return nil
+	}
+	return fmt.Errorf("invalid sort order %q (use version, date or size)", order)
+}
+
+func (o ListOptions) matches(v VersionInfo, templatePrefix bool) bool {
+	if templatePrefix && o.Prefix != "" && v.Values["prefix"] != strings.Trim(o.Prefix, "/") {
+		return false
+	}
+	if !o.Since.IsZero() && v.LastModified.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && !v.LastModified.Before(o.Until) {
+		return false
+	}
+	if o.Commit != "" && !strings.HasPrefix(v.Commit, o.Commit) {
+		return false
+	}
+	if o.Branch != "" && v.Values["branch"] != strings.ReplaceAll(o.Branch, "/", "-") {
+		return false
+	}
+	if o.Name != "" {
+		if ok, _ := path.Match(o.Name, v.Values["filename"]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sortKey holds what ListOptions can sort an item by
+type sortKey struct {
+	version string
+	date    time.Time
+	size    int64
+}
+
+// order returns the indexes of n items in the order the options select,
+// limited to Limit; key describes item i. Both objects and version groups
+// are ordered with it.
+func (o ListOptions) order(n int, key func(i int) sortKey) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	var less func(a, b sortKey) bool
+	switch o.Sort {
+	case SortVersion:
+		less = func(a, b sortKey) bool { return CompareVersions(a.version, b.version) < 0 }
+	case SortDate:
+		less = func(a, b sortKey) bool { return a.date.Before(b.date) }
+	case SortSize:
+		less = func(a, b sortKey) bool { return a.size < b.size }
+	}
+	if less != nil {
+		sort.SliceStable(idx, func(i, j int) bool {
+			if o.Reverse {
+				return less(key(idx[j]), key(idx[i]))
+			}
+			return less(key(idx[i]), key(idx[j]))
+		})
+	} else if o.Reverse {
+		for i, j := 0, len(idx)-1; i < j; i, j = i+1, j-1 {
+			idx[i], idx[j] = idx[j], idx[i]
+		}
+	}
+
+	if o.Limit > 0 && len(idx) > o.Limit {
+		idx = idx[:o.Limit]
+	}
+	return idx
+}
+
+// apply sorts and truncates the filtered results
+func (o ListOptions) apply(versions []VersionInfo) []VersionInfo {
+	idx := o.order(len(versions), func(i int) sortKey {
+		return sortKey{versions[i].Version, versions[i].LastModified, versions[i].SizeBytes}
+	})
+	out := make([]VersionInfo, len(idx))
+	for i, j := range idx {
+		out[i] = versions[j]
+	}
+	return out
+}
+
+// CompareVersions orders generated versions: first by the numeric
+// vMAJOR.MINOR.PATCH core, then by the embedded YYYYMMDD-HHMMSS timestamp,
+// then by the remaining text. Returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	if c := compareCore(versionCore(a), versionCore(b)); c != 0 {
+		return c
+	}
+	if c := strings.Compare(versionTimestamp(a), versionTimestamp(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func versionCore(v string) []string {
+	core := strings.SplitN(strings.TrimPrefix(v, "v"), "-", 2)[0]
+	return strings.Split(core, ".")
+}
+
+func compareCore(a, b []string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x, _ = strconv.Atoi(a[i])
+		}
+		if i < len(b) {
+			y, _ = strconv.Atoi(b[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionTimestamp returns the first YYYYMMDD part joined with a following
+// HHMMSS part, or "" when the version carries no date
+func versionTimestamp(v string) string {
+	parts := versionParts(v)
+	date, clock := versionDateParts(parts)
+	switch {
+	case date < 0:
+		return ""
+	case clock < 0:
+		return parts[date] + "000000"
+	}
+	return parts[date] + parts[clock]
+}
+
+// versionParts splits a version at '-', dropping the leading 'v' so that
+// formats like v{date}-{build} start with the date
+func versionParts(v string) []string {
+	return strings.Split(strings.TrimPrefix(v, "v"), "-")
+}
+
+// versionDateParts returns the index of the first YYYYMMDD part and of the
+// HHMMSS part following it, -1 when missing
+func versionDateParts(parts []string) (date, clock int) {
+	for i, part := range parts {
+		if len(part) == 8 && isDigits(part) {
+			if i+1 < len(parts) && len(parts[i+1]) == 6 && isDigits(parts[i+1]) {
+				return i, i + 1
+			}
+			return i, -1
+		}
+	}
+	return -1, -1
+}
+
+// VersionTime returns when a version was made, from its {date} and {time}
+// parts wherever the version format puts them
+func VersionTime(v string) (time.Time, bool) {
+	ts := versionTimestamp(v)
+	if ts == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("20060102150405", ts)
+	return t, err == nil
+}
+
+// versionCommit returns the {commit} part of a version: the first part after
+// the leading one, other than the date and time, that looks like a short
+// hash or is "unknown". A hash of digits only must be 7 long, so counters and
+// build numbers are not taken for one. It is "" for formats without {commit}.
+func versionCommit(v string) string {
+	parts := versionParts(v)
+	date, clock := versionDateParts(parts)
+	for i := 1; i < len(parts); i++ {
+		if i == date || i == clock {
+			continue
+		}
+		part := parts[i]
+		if part == "unknown" || (isHex(part) && (len(part) >= 7 || !isDigits(part))) {
+			return part
+		}
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// maxVersionDirDepth bounds how deep ListVersionDirs descends looking for
+// version directories below a multi-segment {prefix}
+const maxVersionDirDepth = 16
+
+// VersionDir is a version found as a common prefix of a delimiter listing
+type VersionDir struct {
+	Version string
+	// Prefix is the key prefix holding the version's objects, ending in "/"
+	Prefix string
+	// Values holds the key template placeholders matched in Prefix
+	Values map[string]string
+}
+
+// ListVersionDirs finds versions by listing "directories" with a '/'
+// delimiter down to the {version} segment of the key template, without
+// listing the objects inside each version. prefix is the upload prefix, as
+// in ListOptions.Prefix.
+func (c *Client) ListVersionDirs(prefix string) ([]VersionDir, error) {
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+
+	tmpl := c.keyTemplate()
+	start := prefix
+	if tmpl.Uses("prefix") {
+		start = tmpl.ListPrefix(map[string]string{"prefix": prefix})
+	}
+
+	var dirs []VersionDir
+	if err := c.walkVersionDirs(start, 0, &dirs); err != nil {
+		return nil, err
+	}
+
+	if prefix != "" && tmpl.Uses("prefix") {
+		filtered := dirs[:0]
+		for _, d := range dirs {
+			if d.Values["prefix"] == strings.Trim(prefix, "/") {
+				filtered = append(filtered, d)
+			}
+		}
+		dirs = filtered
+	}
+	return dirs, nil
+}
+
+func (c *Client) walkVersionDirs(dir string, depth int, dirs *[]VersionDir) error {
+	if depth > maxVersionDirDepth {
+		return nil
+	}
+	commonPrefixes, err := c.listCommonPrefixes(dir)
+	if err != nil {
+		return err
+	}
+	tmpl := c.keyTemplate()
+	for _, cp := range commonPrefixes {
+		if isTrashKey(cp) {
+			continue
+		}
+		if values, ok := tmpl.MatchVersionDir(cp); ok {
+			*dirs = append(*dirs, VersionDir{Version: values["version"], Prefix: cp, Values: values})
+			continue
+		}
+		if tmpl.CanContainVersion(cp) {
+			if err := c.walkVersionDirs(cp, depth+1, dirs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// listCommonPrefixes returns the "subdirectories" directly below prefix
+func (c *Client) listCommonPrefixes(prefix string) ([]string, error) {
+	var prefixes []string
+	marker := ""
+	for {
+		input := &huaweicloudsdkobs.ListObjectsInput{
+			ListObjsInput: huaweicloudsdkobs.ListObjsInput{
+				Prefix:    prefix,
+				Delimiter: "/",
+			},
+			Bucket: c.Bucket,
+			Marker: marker,
+		}
+
+		output, err := c.client.ListObjects(input)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, output.CommonPrefixes...)
+
+		if !output.IsTruncated {
+			break
+		}
+		marker = output.NextMarker
+	}
+	return prefixes, nil
+}
+
+// ListVersionFiles lists the objects of one version
+func (c *Client) ListVersionFiles(dir VersionDir) ([]VersionInfo, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	var files []VersionInfo
+	marker := ""
+	for {
+		input := &huaweicloudsdkobs.ListObjectsInput{
+			ListObjsInput: huaweicloudsdkobs.ListObjsInput{
+				Prefix: dir.Prefix,
+			},
+			Bucket: c.Bucket,
+			Marker: marker,
+		}
+
+		output, err := c.client.ListObjects(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range output.Contents {
+			values, ok := c.keyTemplate().Match(obj.Key)
+			if !ok || values["version"] != dir.Version {
+				continue
+			}
+			files = append(files, VersionInfo{
+				Key:          obj.Key,
+				Size:         formatSize(obj.Size),
+				Date:         obj.LastModified.Format("2006-01-02"),
+				Commit:       versionCommit(dir.Version),
+				Version:      dir.Version,
+				URL:          c.GetDownloadURL(obj.Key),
+				Values:       values,
+				SizeBytes:    obj.Size,
+				LastModified: obj.LastModified,
+			})
+		}
+
+		if !output.IsTruncated {
+			break
+		}
+		marker = output.NextMarker
+	}
+	return files, nil
+}
+
+// VersionGroup aggregates the objects of one version
+type VersionGroup struct {
+	Version string
+	// Prefix is the key prefix of the version, ending in "/"
+	Prefix       string
+	Commit       string
+	Files        []VersionInfo
+	TotalSize    int64
+	LastModified time.Time
+}
+
+// Filenames returns the file names in the version
+func (g VersionGroup) Filenames() []string {
+	names := make([]string, 0, len(g.Files))
+	for _, f := range g.Files {
+		names = append(names, f.Values["filename"])
+	}
+	return names
+}
+
+// TagMetadataKey is the object metadata key holding the git tag a version
+// was built from
+const TagMetadataKey = "tag"
+
+// PlatformMetadataKey is the object metadata key holding the platform a
+// file was uploaded for
+const PlatformMetadataKey = "platform"
+
+// VersionTag returns the git tag recorded on the version's objects, or ""
+// for untagged versions. Only the first object is inspected since put tags
+// every file of a version alike.
+func (c *Client) VersionTag(g VersionGroup) (string, error) {
+	if len(g.Files) == 0 {
+		return "", nil
+	}
+	return c.objectMetadata(g.Files[0].Key, TagMetadataKey)
+}
+
+// FilePlatform returns the platform of a file: from {os} and {arch} in the
+// key template, else from the metadata put stored, else inferred from the
+// key. The metadata is only read when the key template has no platform.
+func (c *Client) FilePlatform(v VersionInfo) (platform.Platform, bool, error) {
+	if p, ok := v.templatePlatform(); ok {
+		return p, true, nil
+	}
+	value, err := c.objectMetadata(v.Key, PlatformMetadataKey)
+	if err != nil {
+		return platform.Platform{}, false, err
+	}
+	if p, err := platform.Parse(value); value != "" && err == nil {
+		return p, true, nil
+	}
+	p, ok := platform.Infer(v.Key)
+	return p, ok, nil
+}
+
+// objectMetadata returns one user metadata value of an object, or ""
+func (c *Client) objectMetadata(key, name string) (string, error) {
+	if err := c.ensureConnected(); err != nil {
+		return "", err
+	}
+	input := &huaweicloudsdkobs.GetObjectMetadataInput{
+		Bucket: c.Bucket,
+		Key:    key,
+	}
+	output, err := c.client.GetObjectMetadata(input)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range output.Metadata {
+		if strings.EqualFold(k, name) {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// GroupVersions groups objects by version directory, keeping the order in
+// which versions first appear. The same version under two prefixes forms two
+// groups.
+func GroupVersions(files []VersionInfo) []VersionGroup {
+	var groups []VersionGroup
+	index := make(map[string]int)
+	for _, f := range files {
+		prefix := versionPrefix(f.Key, f.Version)
+		i, ok := index[prefix]
+		if !ok {
+			i = len(groups)
+			index[prefix] = i
+			groups = append(groups, VersionGroup{Version: f.Version, Prefix: prefix, Commit: f.Commit})
+		}
+		groups[i].add(f)
+	}
+	return groups
+}
+
+// add puts an object into the group
+func (g *VersionGroup) add(f VersionInfo) {
+	g.Files = append(g.Files, f)
+	g.TotalSize += f.SizeBytes
+	if f.LastModified.After(g.LastModified) {
+		g.LastModified = f.LastModified
+	}
+}
+
+// ListVersionGroups lists the versions matching opts with their files.
+// Versions are found with ListVersionDirs and filtered by commit and branch
+// before their files are listed. For key and version order, which do not
+// depend on the files, listing stops once Limit versions are found.
+func (c *Client) ListVersionGroups(opts ListOptions) ([]VersionGroup, error) {
+	tmpl := c.keyTemplate()
+	if opts.Branch != "" && !tmpl.Uses("branch") {
+		return nil, fmt.Errorf("cannot filter by branch: key template %q has no {branch}", tmpl)
+	}
+	if opts.Name != "" {
+		if _, err := path.Match(opts.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %v", opts.Name, err)
+		}
+	}
+
+	dirs, err := c.ListVersionDirs(opts.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	kept := dirs[:0]
+	for _, d := range dirs {
+		if opts.Commit != "" && !strings.HasPrefix(versionCommit(d.Version), opts.Commit) {
+			continue
+		}
+		// {branch} after {version} is only known from the files
+		if branch, ok := d.Values["branch"]; ok && opts.Branch != "" && branch != strings.ReplaceAll(opts.Branch, "/", "-") {
+			continue
+		}
+		kept = append(kept, d)
+	}
+	dirs = kept
+
+	early := opts.Sort == "" || opts.Sort == SortVersion
+	if early {
+		unlimited := opts
+		unlimited.Limit = 0
+		idx := unlimited.order(len(dirs), func(i int) sortKey { return sortKey{version: dirs[i].Version} })
+		ordered := make([]VersionDir, len(idx))
+		for i, j := range idx {
+			ordered[i] = dirs[j]
+		}
+		dirs = ordered
+	}
+
+	var groups []VersionGroup
+	for _, d := range dirs {
+		files, err := c.ListVersionFiles(d)
+		if err != nil {
+			return nil, err
+		}
+		g := VersionGroup{Version: d.Version, Prefix: d.Prefix, Commit: versionCommit(d.Version)}
+		for _, f := range files {
+			if opts.matches(f, tmpl.Uses("prefix")) {
+				g.add(f)
+			}
+		}
+		if len(g.Files) == 0 {
+			continue
+		}
+		groups = append(groups, g)
+		if early && opts.Limit > 0 && len(groups) >= opts.Limit {
+			return groups, nil
+		}
+	}
+	if early {
+		return groups, nil
+	}
+	return opts.ApplyGroups(groups), nil
+}
+
+// versionPrefix returns the part of key up to and including the version segment
+func versionPrefix(key, version string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		if part == version {
+			return strings.Join(parts[:i+1], "/") + "/"
+		}
+	}
+	return version + "/"
+}
+
+// ApplyGroups sorts and truncates version groups the way apply does for
+// objects: by version, newest file date or total size, limited to Limit groups
+func (o ListOptions) ApplyGroups(groups []VersionGroup) []VersionGroup {
+	idx := o.order(len(groups), func(i int) sortKey {
+		return sortKey{groups[i].Version, groups[i].LastModified, groups[i].TotalSize}
+	})
+	out := make([]VersionGroup, len(idx))
+	for i, j := range idx {
+		out[i] = groups[j]
+	}
+	return out
+}
@@ -0,0 +1,426 @@
+package obs
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{
+		"v1.0.0-abc-20260101-120000-1",
+		"v1.0.0-fff-20260102-090000-1",
+		"v1.0.0-aaa-20260102-100000-1",
+		"v1.2.0-aaa-20250101-000000-1",
+		"v1.10.0-aaa-20240101-000000-1",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		if CompareVersions(ordered[i], ordered[i+1]) >= 0 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+		if CompareVersions(ordered[i+1], ordered[i]) <= 0 {
+			t.Errorf("expected %s > %s", ordered[i+1], ordered[i])
+		}
+	}
+}
+
+func TestValidateSort(t *testing.T) {
+	for _, order := range []string{"", "version", "date", "size"} {
+		if err := ValidateSort(order); err != nil {
+			t.Errorf("ValidateSort(%q) failed: %v", order, err)
+		}
+	}
+	if err := ValidateSort("name"); err == nil {
+		t.Error("expected error for unknown sort order")
+	}
+}
+
+func seedVersions(f *fakeServer) {
+	day := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	f.put("v1.0.0-aaa111-20260210-120000-1/app-linux-amd64", 300, day)
+	f.put("v1.0.0-aaa111-20260210-120000-1/app-darwin-arm64", 100, day)
+	f.put("v1.0.0-bbb222-20260211-120000-1/app-linux-amd64", 200, day.AddDate(0, 0, 1))
+	f.put("releases/v1.0.0-ccc333-20260212-120000-1/app-linux-amd64", 50, day.AddDate(0, 0, 2))
+	f.put("releases/nightly/v1.0.0-ddd444-20260213-120000-1/app", 10, day.AddDate(0, 0, 3))
+	f.put(".obsput/build-number", 1, day)
+}
+
+func TestListVersionsWithOptions(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.pageSize = 2
+	seedVersions(f)
+
+	all, err := client.ListVersionsWithOptions(ListOptions{})
+	if err != nil {
+		t.Fatalf("ListVersionsWithOptions failed: %v", err)
+	}
+	if len(all) != 5 {
+		t.Errorf("expected 5 version objects across pages, got %d", len(all))
+	}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		keys []string
+	}{
+		{
+			name: "prefix is exact",
+			opts: ListOptions{Prefix: "releases"},
+			keys: []string{"releases/v1.0.0-ccc333-20260212-120000-1/app-linux-amd64"},
+		},
+		{
+			name: "commit",
+			opts: ListOptions{Commit: "aaa", Sort: SortSize},
+			keys: []string{"v1.0.0-aaa111-20260210-120000-1/app-darwin-arm64", "v1.0.0-aaa111-20260210-120000-1/app-linux-amd64"},
+		},
+		{
+			name: "name glob and date range",
+			opts: ListOptions{Name: "*linux*", Since: time.Date(2026, 2, 11, 0, 0, 0, 0, time.UTC), Until: time.Date(2026, 2, 12, 0, 0, 0, 0, time.UTC)},
+			keys: []string{"v1.0.0-bbb222-20260211-120000-1/app-linux-amd64"},
+		},
+		{
+			name: "newest first with limit",
+			opts: ListOptions{Sort: SortDate, Reverse: true, Limit: 2},
+			keys: []string{"releases/nightly/v1.0.0-ddd444-20260213-120000-1/app", "releases/v1.0.0-ccc333-20260212-120000-1/app-linux-amd64"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := client.ListVersionsWithOptions(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: ListVersionsWithOptions failed: %v", tt.name, err)
+		}
+		if len(got) != len(tt.keys) {
+			t.Errorf("%s: expected %d results, got %d", tt.name, len(tt.keys), len(got))
+			continue
+		}
+		for i, key := range tt.keys {
+			if got[i].Key != key {
+				t.Errorf("%s: result %d: expected %s, got %s", tt.name, i, key, got[i].Key)
+			}
+		}
+	}
+}
+
+func TestListVersionsLimitStopsPaging(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.pageSize = 1
+	seedVersions(f)
+
+	got, err := client.ListVersionsWithOptions(ListOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListVersionsWithOptions failed: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("expected 1 result, got %d", len(got))
+	}
+	if f.listCalls > 2 {
+		t.Errorf("unsorted limited listing should stop paging early, made %d list calls", f.listCalls)
+	}
+}
+
+func TestListVersionsLimitReverse(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.pageSize = 1
+	seedVersions(f)
+
+	all, err := client.ListVersionsWithOptions(ListOptions{})
+	if err != nil {
+		t.Fatalf("ListVersionsWithOptions failed: %v", err)
+	}
+	got, err := client.ListVersionsWithOptions(ListOptions{Limit: 1, Reverse: true})
+	if err != nil {
+		t.Fatalf("ListVersionsWithOptions failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Key != all[len(all)-1].Key {
+		t.Errorf("--limit 1 --reverse should return the last key %s, got %v", all[len(all)-1].Key, got)
+	}
+}
+
+func TestListVersionsBranchNeedsTemplate(t *testing.T) {
+	client := NewClient("obs.test.com", "bucket", "ak", "sk")
+	if _, err := client.ListVersionsWithOptions(ListOptions{Branch: "main"}); err == nil {
+		t.Error("expected error filtering by branch without {branch} in the template")
+	}
+}
+
+func TestListVersionDirs(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+	// Many files in one version must not cost extra listing calls
+	for i := 0; i < 50; i++ {
+		f.put("v1.0.0-eee555-20260214-120000-1/file-"+strconv.Itoa(i), 1, time.Now())
+	}
+
+	dirs, err := client.ListVersionDirs("")
+	if err != nil {
+		t.Fatalf("ListVersionDirs failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, d := range dirs {
+		got[d.Prefix] = d.Version
+	}
+	want := map[string]string{
+		"v1.0.0-aaa111-20260210-120000-1/":                  "v1.0.0-aaa111-20260210-120000-1",
+		"v1.0.0-bbb222-20260211-120000-1/":                  "v1.0.0-bbb222-20260211-120000-1",
+		"releases/v1.0.0-ccc333-20260212-120000-1/":         "v1.0.0-ccc333-20260212-120000-1",
+		"releases/nightly/v1.0.0-ddd444-20260213-120000-1/": "v1.0.0-ddd444-20260213-120000-1",
+		"v1.0.0-eee555-20260214-120000-1/":                  "v1.0.0-eee555-20260214-120000-1",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d version dirs, got %v", len(want), got)
+	}
+	for prefix, version := range want {
+		if got[prefix] != version {
+			t.Errorf("expected %s -> %s, got %q", prefix, version, got[prefix])
+		}
+	}
+	// root, .obsput/, releases/, releases/nightly/
+	if f.listCalls != 4 {
+		t.Errorf("expected 4 delimiter listings, got %d", f.listCalls)
+	}
+
+	dirs, err = client.ListVersionDirs("releases")
+	if err != nil {
+		t.Fatalf("ListVersionDirs failed: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0].Version != "v1.0.0-ccc333-20260212-120000-1" {
+		t.Errorf("expected only the releases version, got %+v", dirs)
+	}
+}
+
+func TestListVersionGroups(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+
+	groups, err := client.ListVersionGroups(ListOptions{Commit: "bbb"})
+	if err != nil {
+		t.Fatalf("ListVersionGroups failed: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Version != "v1.0.0-bbb222-20260211-120000-1" {
+		t.Errorf("expected only the bbb222 version, got %+v", groups)
+	}
+	// 4 delimiter listings, and files only for the matching version
+	if f.listCalls != 5 {
+		t.Errorf("expected 5 list calls, got %d", f.listCalls)
+	}
+
+	f.listCalls = 0
+	groups, err = client.ListVersionGroups(ListOptions{Sort: SortVersion, Reverse: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("ListVersionGroups failed: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Version != "v1.0.0-ddd444-20260213-120000-1" {
+		t.Errorf("expected the highest version, got %+v", groups)
+	}
+	if f.listCalls != 5 {
+		t.Errorf("a version-ordered limit should list one version's files, made %d list calls", f.listCalls)
+	}
+
+	groups, err = client.ListVersionGroups(ListOptions{Name: "*darwin*"})
+	if err != nil {
+		t.Fatalf("ListVersionGroups failed: %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Files) != 1 || groups[0].TotalSize != 100 {
+		t.Errorf("expected one darwin file, got %+v", groups)
+	}
+
+	groups, err = client.ListVersionGroups(ListOptions{Sort: SortSize, Reverse: true, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListVersionGroups failed: %v", err)
+	}
+	if len(groups) != 2 || groups[0].TotalSize != 400 || groups[1].TotalSize != 200 {
+		t.Errorf("unexpected size ordering: %+v", groups)
+	}
+
+	if _, err := client.ListVersionGroups(ListOptions{Branch: "main"}); err == nil {
+		t.Error("expected error filtering by branch without {branch} in the template")
+	}
+}
+
+func TestListVersionFilesAndDeleteVersionGroup(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+
+	dir := VersionDir{Version: "v1.0.0-aaa111-20260210-120000-1", Prefix: "v1.0.0-aaa111-20260210-120000-1/"}
+	files, err := client.ListVersionFiles(dir)
+	if err != nil {
+		t.Fatalf("ListVersionFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected 2 files, got %d", len(files))
+	}
+
+	g := VersionGroup{Version: dir.Version, Prefix: dir.Prefix, Files: files}
+	if result := client.DeleteVersionGroup(g); !result.Success {
+		t.Fatalf("DeleteVersionGroup failed: %s", result.Error)
+	}
+	for _, key := range f.keys() {
+		if strings.HasPrefix(key, dir.Prefix) {
+			t.Errorf("expected %s to be deleted", key)
+		}
+	}
+	if len(f.keys()) != 4 {
+		t.Errorf("expected other versions to remain, got %v", f.keys())
+	}
+}
+
+func TestGroupVersions(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+	f.put("releases/v1.0.0-aaa111-20260210-120000-1/app", 7, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	files, err := client.ListVersions("")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	groups := GroupVersions(files)
+	if len(groups) != 5 {
+		t.Fatalf("expected 5 version groups, got %d", len(groups))
+	}
+
+	var aaa *VersionGroup
+	for i := range groups {
+		if groups[i].Prefix == "v1.0.0-aaa111-20260210-120000-1/" {
+			aaa = &groups[i]
+		}
+	}
+	if aaa == nil {
+		t.Fatal("expected group for unprefixed aaa111 version")
+	}
+	if len(aaa.Files) != 2 || aaa.TotalSize != 400 {
+		t.Errorf("expected 2 files totalling 400 bytes, got %d files, %d bytes", len(aaa.Files), aaa.TotalSize)
+	}
+	if names := aaa.Filenames(); len(names) != 2 || names[0] != "app-darwin-arm64" {
+		t.Errorf("unexpected filenames %v", names)
+	}
+
+	bySize := ListOptions{Sort: SortSize, Reverse: true, Limit: 2}.ApplyGroups(groups)
+	if len(bySize) != 2 || bySize[0].TotalSize != 400 || bySize[1].TotalSize != 200 {
+		t.Errorf("unexpected size ordering: %+v", bySize)
+	}
+
+	byDate := ListOptions{Sort: SortDate, Reverse: true, Limit: 1}.ApplyGroups(groups)
+	if byDate[0].Prefix != "releases/v1.0.0-aaa111-20260210-120000-1/" {
+		t.Errorf("expected newest group first, got %s", byDate[0].Prefix)
+	}
+}
+
+func TestVersionCommitAndTime(t *testing.T) {
+	tests := []struct {
+		version string
+		commit  string
+		time    string
+	}{
+		{"v1.0.0-abc123-20260214-153045-1", "abc123", "20260214153045"},
+		{"v1.0.0-unknown-20260214-153045-1", "unknown", "20260214153045"},
+		{"v2.0.0-20260214-42", "", "20260214000000"},
+		{"v20260214-153045-7", "", "20260214153045"},
+		{"v1-20260214-1234567", "1234567", "20260214000000"},
+		{"v1-20260214-153045-cafe123-12", "cafe123", "20260214153045"},
+		{"v1.0.0-beta", "", ""},
+	}
+	for _, tt := range tests {
+		if got := versionCommit(tt.version); got != tt.commit {
+			t.Errorf("versionCommit(%s) = %q, want %q", tt.version, got, tt.commit)
+		}
+		got, ok := VersionTime(tt.version)
+		if tt.time == "" {
+			if ok {
+				t.Errorf("VersionTime(%s) = %v, want none", tt.version, got)
+			}
+			continue
+		}
+		if !ok || got.Format("20060102150405") != tt.time {
+			t.Errorf("VersionTime(%s) = %v, %v, want %s", tt.version, got, ok, tt.time)
+		}
+	}
+}
+
+func TestListVersionGroupsCommitCustomFormat(t *testing.T) {
+	f, client := newFakeServer(t)
+	now := time.Now()
+	f.put("v2-20260101-120000-abc1234-1/app", 10, now)
+	f.put("v2-20260102-120000-def5678-2/app", 10, now)
+	f.put("v2-20260103-7/app", 10, now)
+
+	groups, err := client.ListVersionGroups(ListOptions{Commit: "def"})
+	if err != nil {
+		t.Fatalf("ListVersionGroups failed: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Version != "v2-20260102-120000-def5678-2" || groups[0].Commit != "def5678" {
+		t.Errorf("expected only the def5678 version, got %+v", groups)
+	}
+}
+
+func TestVersionTag(t *testing.T) {
+	f, client := newFakeServer(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app")
+	if err := os.WriteFile(file, []byte("bin"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	if _, err := client.UploadFileWithMetadata(file, "v1.0.0-abc-20260101-120000-1", "", map[string]string{TagMetadataKey: "v1.0.0"}, nil); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	f.put("v1.0.0-def-20260102-120000-1/app", 3, time.Now())
+
+	versions, err := client.ListVersions("")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	groups := GroupVersions(versions)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(groups))
+	}
+	want := map[string]string{"v1.0.0-abc-20260101-120000-1": "v1.0.0", "v1.0.0-def-20260102-120000-1": ""}
+	for _, g := range groups {
+		tag, err := client.VersionTag(g)
+		if err != nil {
+			t.Fatalf("VersionTag failed: %v", err)
+		}
+		if tag != want[g.Version] {
+			t.Errorf("VersionTag(%s) = %q, want %q", g.Version, tag, want[g.Version])
+		}
+	}
+}
+
+func TestFilePlatform(t *testing.T) {
+	f, client := newFakeServer(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app")
+	if err := os.WriteFile(file, []byte("bin"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	// The default key template has no {os}/{arch}: only the metadata knows
+	if _, err := client.UploadFileWithMetadata(file, "v1.0.0-abc-20260101-120000-1", "", map[string]string{PlatformMetadataKey: "linux/arm64"}, nil); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	f.put("v1.0.0-abc-20260101-120000-1/app-darwin-amd64", 3, time.Now())
+	f.put("v1.0.0-abc-20260101-120000-1/notes.txt", 3, time.Now())
+
+	versions, err := client.ListVersions("")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	want := map[string]string{"app": "linux/arm64", "app-darwin-amd64": "darwin/amd64", "notes.txt": ""}
+	for _, v := range versions {
+		name := path.Base(v.Key)
+		p, ok, err := client.FilePlatform(v)
+		if err != nil {
+			t.Fatalf("FilePlatform(%s) failed: %v", v.Key, err)
+		}
+		got := ""
+		if ok {
+			got = p.String()
+		}
+		if got != want[name] {
+			t.Errorf("FilePlatform(%s) = %q, want %q", name, got, want[name])
+		}
+	}
+}
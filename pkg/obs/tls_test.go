@@ -0,0 +1,63 @@
+package obs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTLSOptionsValidate(t *testing.T) {
+	dir := t.TempDir()
+	pem := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(pem, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	var none *TLSOptions
+	if err := none.Validate(); err != nil {
+		t.Errorf("nil options should be valid, got %v", err)
+	}
+	if err := (&TLSOptions{ClientCert: pem}).Validate(); err == nil {
+		t.Error("a client certificate without a key should be rejected")
+	}
+	if err := (&TLSOptions{CABundle: filepath.Join(dir, "missing.pem")}).Validate(); err == nil {
+		t.Error("a missing ca_bundle should be rejected")
+	}
+	if err := (&TLSOptions{CABundle: pem, InsecureSkipVerify: true}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTLSOptionsConfig(t *testing.T) {
+	var none *TLSOptions
+	cfg, err := none.Config("obs.test.com")
+	if err != nil || cfg.ServerName != "obs.test.com" || cfg.InsecureSkipVerify {
+		t.Errorf("unexpected default config %+v, %v", cfg, err)
+	}
+
+	cfg, err = (&TLSOptions{InsecureSkipVerify: true}).Config("obs.test.com")
+	if err != nil || !cfg.InsecureSkipVerify {
+		t.Errorf("insecure_skip_verify not applied: %+v, %v", cfg, err)
+	}
+
+	pem := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(pem, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := (&TLSOptions{CABundle: pem}).Config("obs.test.com"); err == nil {
+		t.Error("a ca_bundle without certificates should be rejected")
+	}
+}
+
+func TestConnectWithTLSOptions(t *testing.T) {
+	client := NewClient("https://obs.test.com", "bucket", "ak", "sk")
+	client.TLS = &TLSOptions{CABundle: filepath.Join(t.TempDir(), "missing.pem")}
+	if err := client.Connect(); err == nil {
+		t.Error("Connect should fail when the ca_bundle cannot be read")
+	}
+
+	client.TLS = &TLSOptions{InsecureSkipVerify: true}
+	if err := client.Connect(); err != nil {
+		t.Errorf("Connect failed: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package obs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	huaweicloudsdkobs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// TrashPrefix holds soft-deleted objects as .trash/<timestamp>/<original key>
+const TrashPrefix = ".trash/"
+
+// trashStampFormat is the UTC timestamp segment of trash keys
+const trashStampFormat = "20060102-150405"
+
+// isTrashKey reports whether key is a soft-deleted object; listings of
+// versions skip them
+func isTrashKey(key string) bool {
+	return strings.HasPrefix(key, TrashPrefix)
+}
+
+// trashKey returns where key is kept when soft-deleted at stamp
+func trashKey(stamp, key string) string {
+	return TrashPrefix + stamp + "/" + key
+}
+
+// TrashEntry is one version soft-deleted at one time
+type TrashEntry struct {
+	Version string
+	// Prefix is the original key prefix of the version, ending in "/"
+	Prefix string
+	// Stamp is the timestamp segment of the trash keys
+	Stamp     string
+	DeletedAt time.Time
+	// Keys are the trash keys; trimming TrashPrefix and the stamp gives the
+	// original keys
+	Keys      []string
+	TotalSize int64
+}
+
+// OriginalKey returns the key a trash key was soft-deleted from
+func (e TrashEntry) OriginalKey(key string) string {
+	return strings.TrimPrefix(key, TrashPrefix+e.Stamp+"/")
+}
+
+// TrashVersionGroup soft-deletes the objects of a version as listed in g:
+// each object is copied server-side into the trash, and only objects copied
+// successfully are removed. The result lists the original keys removed or
+// left in place.
+func (c *Client) TrashVersionGroup(g VersionGroup, at time.Time) *DeleteResult {
+	stamp := at.UTC().Format(trashStampFormat)
+	var copied []string
+	var failed []KeyError
+	for _, f := range g.Files {
+		if err := c.copyObject(f.Key, trashKey(stamp, f.Key)); err != nil {
+			failed = append(failed, KeyError{Key: f.Key, Error: fmt.Sprintf("copy to trash: %v", err)})
+			continue
+		}
+		copied = append(copied, f.Key)
+	}
+
+	result := c.deleteKeys(g.Version, copied)
+	return mergeFailures(result, failed, len(g.Files))
+}
+
+// TrashVersionGroups soft-deletes several versions, at most concurrency at a
+// time, all under the same trash timestamp
+func (c *Client) TrashVersionGroups(groups []VersionGroup, concurrency int, at time.Time) []*DeleteResult {
+	return c.forEachVersionGroup(groups, concurrency, func(g VersionGroup) *DeleteResult {
+		return c.TrashVersionGroup(g, at)
+	})
+}
+
+// ListTrash lists soft-deleted versions, most recently deleted first
+func (c *Client) ListTrash() ([]TrashEntry, error) {
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+
+	tmpl := c.keyTemplate()
+	var entries []TrashEntry
+	index := make(map[string]int)
+	marker := ""
+	for {
+		input := &huaweicloudsdkobs.ListObjectsInput{
+			ListObjsInput: huaweicloudsdkobs.ListObjsInput{
+				Prefix: TrashPrefix,
+			},
+			Bucket: c.Bucket,
+			Marker: marker,
+		}
+
+		output, err := c.client.ListObjects(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range output.Contents {
+			stamp, original, ok := strings.Cut(strings.TrimPrefix(obj.Key, TrashPrefix), "/")
+			if !ok {
+				continue
+			}
+			deletedAt, err := time.Parse(trashStampFormat, stamp)
+			if err != nil {
+				continue
+			}
+			values, ok := tmpl.Match(original)
+			if !ok {
+				continue
+			}
+			version := values["version"]
+			prefix := versionPrefix(original, version)
+			id := stamp + "/" + prefix
+			i, ok := index[id]
+			if !ok {
+				i = len(entries)
+				index[id] = i
+				entries = append(entries, TrashEntry{Version: version, Prefix: prefix, Stamp: stamp, DeletedAt: deletedAt})
+			}
+			entries[i].Keys = append(entries[i].Keys, obj.Key)
+			entries[i].TotalSize += obj.Size
+		}
+
+		if !output.IsTruncated {
+			break
+		}
+		marker = output.NextMarker
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// ExistingKeys returns the original keys of a soft-deleted version that hold
+// an object now, which RestoreTrash would overwrite
+func (c *Client) ExistingKeys(e TrashEntry) ([]string, error) {
+	files, err := c.ListVersionFiles(VersionDir{Version: e.Version, Prefix: e.Prefix})
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool, len(files))
+	for _, f := range files {
+		live[f.Key] = true
+	}
+	var existing []string
+	for _, key := range e.Keys {
+		if original := e.OriginalKey(key); live[original] {
+			existing = append(existing, original)
+		}
+	}
+	return existing, nil
+}
+
+// RestoreTrash copies a soft-deleted version back to its original keys, then
+// removes it from the trash. Existing objects at the original keys are
+// overwritten. Deleted in the result lists the trash keys removed.
+func (c *Client) RestoreTrash(e TrashEntry) *DeleteResult {
+	if err := c.ensureConnected(); err != nil {
+		return failedDelete(e.Version, err)
+	}
+
+	var copied []string
+	var failed []KeyError
+	for _, key := range e.Keys {
+		if err := c.copyObject(key, e.OriginalKey(key)); err != nil {
+			failed = append(failed, KeyError{Key: key, Error: fmt.Sprintf("restore: %v", err)})
+			continue
+		}
+		copied = append(copied, key)
+	}
+
+	result := c.deleteKeys(e.Version, copied)
+	return mergeFailures(result, failed, len(e.Keys))
+}
+
+// EmptyTrash permanently deletes a soft-deleted version
+func (c *Client) EmptyTrash(e TrashEntry) *DeleteResult {
+	return c.deleteKeys(e.Version, e.Keys)
+}
+
+// copyObject copies an object within the bucket, keeping its metadata
+func (c *Client) copyObject(from, to string) error {
+	input := &huaweicloudsdkobs.CopyObjectInput{}
+	input.Bucket = c.Bucket
+	input.Key = to
+	input.CopySourceBucket = c.Bucket
+	input.CopySourceKey = from
+	input.MetadataDirective = huaweicloudsdkobs.CopyMetadata
+	_, err := c.client.CopyObject(input)
+	return err
+}
+
+// mergeFailures adds keys that failed before the delete step to result
+func mergeFailures(result *DeleteResult, failed []KeyError, total int) *DeleteResult {
+	if len(failed) == 0 {
+		return result
+	}
+	result.Failed = append(failed, result.Failed...)
+	result.Success = false
+	result.Error = fmt.Sprintf("%d of %d object(s) not deleted: %s", len(result.Failed), total, result.Failed[0].Error)
+	return result
+}
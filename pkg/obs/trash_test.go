@@ -0,0 +1,111 @@
+package obs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+	at := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+	dir := VersionDir{Version: "v1.0.0-ccc333-20260212-120000-1", Prefix: "releases/v1.0.0-ccc333-20260212-120000-1/"}
+	original := "releases/v1.0.0-ccc333-20260212-120000-1/app-linux-amd64"
+
+	results := client.TrashVersionGroups(listGroups(t, client, dir), 2, at)
+	if !results[0].Success || len(results[0].Deleted) != 1 {
+		t.Fatalf("TrashVersionGroups failed: %+v", results[0])
+	}
+	if _, ok := f.objects[original]; ok {
+		t.Errorf("expected %s to be removed", original)
+	}
+	trashed := ".trash/20260301-083000/" + original
+	if _, ok := f.objects[trashed]; !ok {
+		t.Fatalf("expected %s in the trash, got %v", trashed, f.keys())
+	}
+
+	// Trashed versions are hidden from version listings
+	dirs, err := client.ListVersionDirs("")
+	if err != nil {
+		t.Fatalf("ListVersionDirs failed: %v", err)
+	}
+	for _, d := range dirs {
+		if d.Version == dir.Version {
+			t.Errorf("trashed version listed: %+v", d)
+		}
+	}
+	files, err := client.ListVersions("")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	for _, v := range files {
+		if isTrashKey(v.Key) {
+			t.Errorf("trash key listed: %s", v.Key)
+		}
+	}
+
+	entries, err := client.ListTrash()
+	if err != nil {
+		t.Fatalf("ListTrash failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one trash entry, got %+v", entries)
+	}
+	e := entries[0]
+	if e.Version != dir.Version || e.Prefix != dir.Prefix || !e.DeletedAt.Equal(at) || e.TotalSize != 50 {
+		t.Errorf("unexpected trash entry: %+v", e)
+	}
+
+	if existing, err := client.ExistingKeys(e); err != nil || len(existing) != 0 {
+		t.Errorf("ExistingKeys = %v, %v; want none", existing, err)
+	}
+	// A new upload at the original key would be overwritten by the restore
+	f.put(original, 7, at)
+	if existing, err := client.ExistingKeys(e); err != nil || len(existing) != 1 || existing[0] != original {
+		t.Errorf("ExistingKeys = %v, %v; want %s", existing, err, original)
+	}
+
+	if result := client.RestoreTrash(e); !result.Success {
+		t.Fatalf("RestoreTrash failed: %s", result.Error)
+	}
+	if _, ok := f.objects[original]; !ok {
+		t.Errorf("expected %s to be restored", original)
+	}
+	if _, ok := f.objects[trashed]; ok {
+		t.Errorf("expected %s to leave the trash", trashed)
+	}
+}
+
+func TestTrashKeepsObjectsThatCannotBeRemoved(t *testing.T) {
+	f, client := newFakeServer(t)
+	seedVersions(f)
+	denied := "v1.0.0-aaa111-20260210-120000-1/app-darwin-arm64"
+	f.denyDelete = map[string]bool{denied: true}
+
+	g := listGroups(t, client, VersionDir{Version: "v1.0.0-aaa111-20260210-120000-1", Prefix: "v1.0.0-aaa111-20260210-120000-1/"})[0]
+	result := client.TrashVersionGroup(g, time.Now())
+	if result.Success || len(result.Failed) != 1 || result.Failed[0].Key != denied {
+		t.Fatalf("expected %s to be reported as remaining, got %+v", denied, result)
+	}
+	if _, ok := f.objects[denied]; !ok {
+		t.Errorf("expected %s to remain", denied)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.put(".trash/20260101-000000/v1.0.0-aaa111-20260210-120000-1/app", 10, time.Now())
+	f.put(".trash/20260101-000000/v1.0.0-aaa111-20260210-120000-1/app.sha256", 1, time.Now())
+	f.put("v1.0.0-bbb222-20260211-120000-1/app", 10, time.Now())
+
+	entries, err := client.ListTrash()
+	if err != nil || len(entries) != 1 || len(entries[0].Keys) != 2 {
+		t.Fatalf("unexpected trash: %+v, %v", entries, err)
+	}
+	if result := client.EmptyTrash(entries[0]); !result.Success {
+		t.Fatalf("EmptyTrash failed: %s", result.Error)
+	}
+	if keys := f.keys(); len(keys) != 1 || keys[0] != "v1.0.0-bbb222-20260211-120000-1/app" {
+		t.Errorf("expected only the live version to remain, got %v", keys)
+	}
+}
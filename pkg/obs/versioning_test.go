@@ -0,0 +1,83 @@
+package obs
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestVersioningRoundTrip(t *testing.T) {
+	_, client := newFakeServer(t)
+
+	status, err := client.GetVersioning()
+	if err != nil || status != VersioningOff {
+		t.Fatalf("GetVersioning on a new bucket = %q, %v, want %q", status, err, VersioningOff)
+	}
+
+	if err := client.SetVersioning(true); err != nil {
+		t.Fatalf("SetVersioning(true) failed: %v", err)
+	}
+	if status, _ := client.GetVersioning(); status != VersioningEnabled {
+		t.Errorf("after enable: %q, want %q", status, VersioningEnabled)
+	}
+
+	if err := client.SetVersioning(false); err != nil {
+		t.Fatalf("SetVersioning(false) failed: %v", err)
+	}
+	if status, _ := client.GetVersioning(); status != VersioningSuspended {
+		t.Errorf("after suspend: %q, want %q", status, VersioningSuspended)
+	}
+}
+
+func TestListObjectVersions(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.versioning = VersioningEnabled
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f.put("v1/app", 10, base)
+	f.put("v1/app", 20, base.Add(time.Hour))
+	f.put("v1/app", 30, base.Add(2*time.Hour))
+	// Shares the prefix but is another object
+	f.put("v1/app.sha256", 64, base.Add(3*time.Hour))
+
+	versions, err := client.ListObjectVersions("v1/app")
+	if err != nil {
+		t.Fatalf("ListObjectVersions failed: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %+v", versions)
+	}
+	for i, size := range []int64{30, 20, 10} {
+		if versions[i].Size != size {
+			t.Errorf("versions[%d].Size = %d, want %d (newest first)", i, versions[i].Size, size)
+		}
+	}
+	if !versions[0].IsLatest || versions[1].IsLatest {
+		t.Errorf("only the newest copy should be latest: %+v", versions)
+	}
+
+	// A historical copy is still reachable by its id
+	old := versions[2]
+	got, err := client.GetObjectVersion("v1/app", old.VersionID)
+	if err != nil {
+		t.Fatalf("GetObjectVersion failed: %v", err)
+	}
+	if got.Size != 10 {
+		t.Errorf("GetObjectVersion size = %d, want 10", got.Size)
+	}
+	if _, err := client.GetObjectVersion("v1/app", "missing"); !IsNotFound(err) {
+		t.Errorf("expected a not found error for an unknown version id, got %v", err)
+	}
+
+	signed, err := client.GetSignedVersionURL("v1/app", old.VersionID, 1)
+	if err != nil {
+		t.Fatalf("GetSignedVersionURL failed: %v", err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("invalid signed URL %q: %v", signed, err)
+	}
+	if u.Query().Get("versionId") != old.VersionID {
+		t.Errorf("signed URL %q does not select version %s", signed, old.VersionID)
+	}
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CI systems that upload results can be reported to
+const (
+	CIGitHub = "github"
+	CIGitLab = "gitlab"
+	CINone   = "none"
+)
+
+// DefaultDotenvFile is the GitLab dotenv report written by default
+const DefaultDotenvFile = "obsput.env"
+
+// WriteGitHubOutput appends upload results to a $GITHUB_OUTPUT file as the
+// step outputs version, url (first uploaded URL), urls (one per line) and
+// results (JSON)
+func WriteGitHubOutput(path string, items []UploadItem) error {
+	results, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	urls := uploadedURLs(items)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "version=%s\n", uploadVersion(items))
+	fmt.Fprintf(&b, "url=%s\n", firstOf(urls))
+	writeGitHubMultiline(&b, "urls", strings.Join(urls, "\n"))
+	writeGitHubMultiline(&b, "results", string(results))
+	return appendFile(path, b.String())
+}
+
+// writeGitHubMultiline writes name<<delimiter syntax for values with newlines
+func writeGitHubMultiline(w io.Writer, name, value string) {
+	delimiter := "OBSPUT_EOF"
+	for strings.Contains(value, delimiter) {
+		delimiter += "_"
+	}
+	fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)
+}
+
+// WriteGitHubStepSummary appends a markdown table of the uploads to a
+// $GITHUB_STEP_SUMMARY file
+func WriteGitHubStepSummary(path string, items []UploadItem) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### obsput upload `%s`\n\n", uploadVersion(items))
+	b.WriteString("| Profile | File | Platform | Size | MD5 | URL |\n")
+	b.WriteString("|---------|------|----------|------|-----|-----|\n")
+	for _, item := range items {
+		url := item.URL
+		if url != "" {
+			url = fmt.Sprintf("[%s](%s)", markdownCell(item.Key), url)
+		} else {
+			url = markdownCell(item.Status)
+			if item.Error != "" {
+				url += ": " + markdownCell(item.Error)
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCell(item.Profile), markdownCell(item.File), markdownCell(item.Platform),
+			markdownCell(item.Size), markdownCell(item.MD5), url)
+	}
+	b.WriteString("\n")
+	return appendFile(path, b.String())
+}
+
+// WriteDotenv writes a GitLab dotenv report with OBSPUT_VERSION, OBSPUT_URL
+// (first uploaded URL), OBSPUT_URLS (space separated) and OBSPUT_MD5
+func WriteDotenv(path string, items []UploadItem) error {
+	urls := uploadedURLs(items)
+	md5 := ""
+	for _, item := range items {
+		if item.URL != "" {
+			md5 = item.MD5
+			break
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "OBSPUT_VERSION=%s\n", uploadVersion(items))
+	fmt.Fprintf(&b, "OBSPUT_URL=%s\n", firstOf(urls))
+	fmt.Fprintf(&b, "OBSPUT_URLS=%s\n", strings.Join(urls, " "))
+	fmt.Fprintf(&b, "OBSPUT_MD5=%s\n", md5)
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func uploadedURLs(items []UploadItem) []string {
+	var urls []string
+	for _, item := range items {
+		if item.URL != "" {
+			urls = append(urls, item.URL)
+		}
+	}
+	return urls
+}
+
+func uploadVersion(items []UploadItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return items[0].Version
+}
+
+func firstOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// markdownCell keeps a value inside its table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
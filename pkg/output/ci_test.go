@@ -0,0 +1,75 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var ciItems = []UploadItem{
+	{Profile: "prod", File: "app", Platform: "linux/amd64", Version: "v1.0.0-abc", Key: "v1.0.0-abc/app", URL: "https://a/v1.0.0-abc/app", Size: "1.0 KB", MD5: "md5a", Status: "uploaded"},
+	{Profile: "backup", File: "app", Version: "v1.0.0-abc", Status: "failed", Error: "access | denied"},
+	{Profile: "test", File: "app", Version: "v1.0.0-abc", Key: "v1.0.0-abc/app", URL: "https://b/v1.0.0-abc/app", MD5: "md5b", Status: "uploaded"},
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s failed: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestWriteGitHubOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	os.WriteFile(path, []byte("existing=1\n"), 0644)
+
+	if err := WriteGitHubOutput(path, ciItems); err != nil {
+		t.Fatalf("WriteGitHubOutput failed: %v", err)
+	}
+	got := readFile(t, path)
+	for _, want := range []string{
+		"existing=1\n",
+		"version=v1.0.0-abc\n",
+		"url=https://a/v1.0.0-abc/app\n",
+		"urls<<OBSPUT_EOF\nhttps://a/v1.0.0-abc/app\nhttps://b/v1.0.0-abc/app\nOBSPUT_EOF\n",
+		`"Profile":"backup"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("GITHUB_OUTPUT missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteGitHubStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary")
+	if err := WriteGitHubStepSummary(path, ciItems); err != nil {
+		t.Fatalf("WriteGitHubStepSummary failed: %v", err)
+	}
+	got := readFile(t, path)
+	for _, want := range []string{
+		"### obsput upload `v1.0.0-abc`",
+		"| prod | app | linux/amd64 | 1.0 KB | md5a | [v1.0.0-abc/app](https://a/v1.0.0-abc/app) |",
+		"| backup | app |  |  |  | failed: access \\| denied |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("step summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteDotenv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultDotenvFile)
+	if err := WriteDotenv(path, ciItems); err != nil {
+		t.Fatalf("WriteDotenv failed: %v", err)
+	}
+	want := "OBSPUT_VERSION=v1.0.0-abc\n" +
+		"OBSPUT_URL=https://a/v1.0.0-abc/app\n" +
+		"OBSPUT_URLS=https://a/v1.0.0-abc/app https://b/v1.0.0-abc/app\n" +
+		"OBSPUT_MD5=md5a\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("dotenv = %q, want %q", got, want)
+	}
+}
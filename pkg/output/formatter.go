@@ -5,20 +5,202 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"text/template"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
 type VersionItem struct {
+	Profile  string `json:",omitempty" yaml:",omitempty"`
+	Version  string
+	Filename string `json:",omitempty" yaml:",omitempty"`
+	Size     string
+	Date     string
+	Commit   string
+	URL      string
+}
+
+// VersionGroupItem is one version with its file set
+type VersionGroupItem struct {
+	Profile   string
+	Version   string
+	Commit    string
+	FileCount int
+	Size      string
+	SizeBytes int64 `table:"-"`
+	// Date is the newest modification time in the version
+	Date      string
+	Filenames []string
+	// Files is only set when the version is expanded
+	Files []VersionItem `json:",omitempty" yaml:",omitempty"`
+}
+
+// UploadItem is the outcome of uploading one file to one profile
+type UploadItem struct {
+	Profile  string
+	File     string
+	Platform string
+	Version  string
+	Key      string `table:"-"`
+	URL      string
+	CleanURL string `table:"-"`
+	Size     string
+	MD5      string
+	Status   string
+	Error    string `json:",omitempty" yaml:",omitempty"`
+}
+
+// DownloadItem is a downloadable file of a version
+type DownloadItem struct {
+	Profile  string
+	Version  string
+	Filename string
+	Platform string
+	Size     string
+	URL      string
+}
+
+// DeleteItem is the outcome of deleting one version from one profile
+type DeleteItem struct {
+	Profile string
 	Version string
+	Prefix  string
+	// Status is deleted, trashed, failed, planned (dry run) or protected
+	Status string
+	// Objects is the number of objects deleted, or planned to be
+	Objects int
 	Size    string
-	Date    string
-	Commit  string
-	URL     string
+	// Reason tells which protection rule kept the version
+	Reason string `json:",omitempty" yaml:",omitempty"`
+	Error  string `json:",omitempty" yaml:",omitempty"`
+	// Remaining lists the keys left behind by a failed delete
+	Remaining []string `json:",omitempty" yaml:",omitempty"`
+}
+
+// TrashItem is one soft-deleted version
+type TrashItem struct {
+	Profile   string
+	Version   string
+	Prefix    string
+	DeletedAt string
+	Files     int
+	Size      string
+	SizeBytes int64 `table:"-"`
+	// Status is set by restore and empty: restored, deleted, planned or failed
+	Status string `json:",omitempty" yaml:",omitempty"`
+	Error  string `json:",omitempty" yaml:",omitempty"`
+}
+
+// PruneItem is the retention verdict for one version
+type PruneItem struct {
+	Profile   string
+	Location  string
+	Version   string
+	Files     int
+	Size      string
+	SizeBytes int64 `table:"-"`
+	// Action is keep, delete (planned), deleted, trashed or failed
+	Action string
+	Reason string
+	Error  string `json:",omitempty" yaml:",omitempty"`
+	// Remaining lists the keys left behind by a failed delete
+	Remaining []string `json:",omitempty" yaml:",omitempty"`
+}
+
+// ProfileItem describes a configured OBS profile; credentials are masked
+type ProfileItem struct {
+	Name     string
+	Endpoint string
+	Bucket   string
+	AK       string `table:"-"`
+	SK       string `table:"-"`
+	// AKSource and SKSource tell where the keys come from, e.g. "config"
+	// or "env OBS_ACCESS_KEY_ID"; empty when a key is missing
+	AKSource string `json:",omitempty" yaml:",omitempty" table:"-"`
+	SKSource string `json:",omitempty" yaml:",omitempty" table:"-"`
+	// Token is the masked security token of temporary credentials
+	Token       string `json:",omitempty" yaml:",omitempty" table:"-"`
+	TokenSource string `json:",omitempty" yaml:",omitempty" table:"-"`
+	KeyTemplate string
+	Project     string `json:",omitempty" yaml:",omitempty"`
+	Retention   string `json:",omitempty" yaml:",omitempty"`
+	TLS         string `json:",omitempty" yaml:",omitempty" table:"-"`
+	HTTP        string `json:",omitempty" yaml:",omitempty" table:"-"`
+	// Lifecycle lists the bucket's lifecycle rules; only obs get fetches them
+	Lifecycle []string `json:",omitempty" yaml:",omitempty" table:"-"`
+}
+
+// LifecycleItem is one bucket lifecycle rule
+type LifecycleItem struct {
+	Profile            string
+	ID                 string
+	Prefix             string
+	Status             string
+	WarmDays           int
+	ColdDays           int
+	ExpireDays         int
+	AbortMultipartDays int
+}
+
+// VersioningItem is the versioning state of a profile's bucket
+type VersioningItem struct {
+	Profile string
+	Bucket  string
+	// Status is Enabled, Suspended or Off
+	Status string
+}
+
+// HistoryItem is one stored copy of an object in a versioned bucket
+type HistoryItem struct {
+	Profile   string
+	Key       string
+	VersionID string
+	Latest    bool
+	// DeleteMarker marks a delete recorded by versioning; it has no data
+	DeleteMarker bool
+	Size         string
+	SizeBytes    int64 `table:"-"`
+	Date         string
+}
+
+// DoctorItem is one diagnostic check of a profile
+type DoctorItem struct {
+	Profile string
+	Check   string
+	// Status is pass, warn, fail or skip
+	Status string
+	Detail string
+	Hint   string `json:",omitempty" yaml:",omitempty"`
+}
+
+// BucketItem is the outcome of creating a profile's bucket
+type BucketItem struct {
+	Profile      string
+	Bucket       string
+	Location     string `json:",omitempty" yaml:",omitempty"`
+	ACL          string `json:",omitempty" yaml:",omitempty"`
+	StorageClass string `json:",omitempty" yaml:",omitempty"`
+	Versioning   bool
+	Status       string
+	Error        string `json:",omitempty" yaml:",omitempty"`
+}
+
+// RemoveBucketItem is the outcome of removing a profile's bucket
+type RemoveBucketItem struct {
+	Profile string
+	Bucket  string
+	// Objects and Uploads count what --force deleted and aborted
+	Objects int
+	Uploads int
+	// Status is removed or failed
+	Status string
+	Error  string `json:",omitempty" yaml:",omitempty"`
 }
 
 type Formatter struct {
-	output io.Writer
+	output   io.Writer
+	format   string
+	template *template.Template
 }
 
 func NewFormatter() *Formatter {
@@ -42,7 +224,7 @@ func (f *Formatter) PrintVersionTable(items []VersionItem) {
 	t.Render()
 }
 
-func (f *Formatter) PrintJSON(items []VersionItem) {
+func (f *Formatter) PrintJSON(items interface{}) {
 	enc := json.NewEncoder(f.output)
 	enc.SetIndent("", "  ")
 	enc.Encode(items)
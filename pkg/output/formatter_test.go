@@ -46,3 +46,23 @@ func TestPrintJSON(t *testing.T) {
 		t.Error("output should contain version v1.0.0")
 	}
 }
+
+func TestPrintJSONVersionGroups(t *testing.T) {
+	f := NewFormatter()
+	buf := bytes.NewBufferString("")
+	f.SetOutput(buf)
+
+	groups := []VersionGroupItem{
+		{Version: "v1.0.0", FileCount: 2, Filenames: []string{"app-linux", "app-darwin"}},
+	}
+
+	f.PrintJSON(groups)
+	output := buf.String()
+
+	if !bytes.Contains([]byte(output), []byte("app-darwin")) {
+		t.Error("output should contain file names")
+	}
+	if bytes.Contains([]byte(output), []byte("\"Files\"")) {
+		t.Error("unexpanded groups should omit Files")
+	}
+}
@@ -0,0 +1,183 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by SetFormat
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatCSV      = "csv"
+	FormatTemplate = "template"
+)
+
+// Formats lists the names accepted by --output
+var Formats = []string{FormatTable, FormatJSON, FormatYAML, FormatCSV, FormatTemplate}
+
+// SetFormat selects how Render prints records. A non-empty tmpl selects the
+// Go template format, e.g. '{{.Version}} {{.URL}}'.
+func (f *Formatter) SetFormat(format, tmpl string) error {
+	if tmpl != "" {
+		if format != "" && format != FormatTable && format != FormatTemplate {
+			return fmt.Errorf("--format cannot be combined with --output %s", format)
+		}
+		t, err := template.New("format").Parse(tmpl)
+		if err != nil {
+			return fmt.Errorf("invalid --format template: %v", err)
+		}
+		f.format = FormatTemplate
+		f.template = t
+		return nil
+	}
+
+	switch format {
+	case "":
+		f.format = FormatTable
+	case FormatTable, FormatJSON, FormatYAML, FormatCSV:
+		f.format = format
+	case FormatTemplate:
+		return fmt.Errorf("--output template requires --format '<go template>'")
+	default:
+		return fmt.Errorf("invalid output format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+	return nil
+}
+
+// Format returns the selected output format
+func (f *Formatter) Format() string {
+	if f.format == "" {
+		return FormatTable
+	}
+	return f.format
+}
+
+// Render prints records, a struct or a slice of structs, in the selected
+// format. Table and CSV columns are the exported fields; fields tagged
+// `table:"-"` and nested struct slices are left out of them.
+func (f *Formatter) Render(records interface{}) error {
+	switch f.Format() {
+	case FormatJSON:
+		f.PrintJSON(records)
+		return nil
+	case FormatYAML:
+		data, err := yaml.Marshal(records)
+		if err != nil {
+			return err
+		}
+		_, err = f.output.Write(data)
+		return err
+	case FormatTemplate:
+		return f.renderTemplate(records)
+	}
+
+	header, rows := tabulate(records)
+	if f.Format() == FormatCSV {
+		w := csv.NewWriter(f.output)
+		w.Write(header)
+		w.WriteAll(rows)
+		return w.Error()
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(f.output)
+	t.SetStyle(table.StyleRounded)
+	headerRow := make(table.Row, len(header))
+	for i, h := range header {
+		headerRow[i] = strings.ToUpper(h)
+	}
+	t.AppendHeader(headerRow)
+	for _, row := range rows {
+		r := make(table.Row, len(row))
+		for i, v := range row {
+			r[i] = v
+		}
+		t.AppendRow(r)
+	}
+	t.Render()
+	return nil
+}
+
+func (f *Formatter) renderTemplate(records interface{}) error {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		if err := f.template.Execute(f.output, records); err != nil {
+			return err
+		}
+		fmt.Fprintln(f.output)
+		return nil
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := f.template.Execute(f.output, v.Index(i).Interface()); err != nil {
+			return err
+		}
+		fmt.Fprintln(f.output)
+	}
+	return nil
+}
+
+// tabulate flattens records into a header and string rows
+func tabulate(records interface{}) ([]string, [][]string) {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		slice := reflect.MakeSlice(reflect.SliceOf(v.Type()), 1, 1)
+		slice.Index(0).Set(v)
+		v = slice
+	}
+
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		rows := make([][]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			rows = append(rows, []string{fmt.Sprint(v.Index(i).Interface())})
+		}
+		return []string{"Value"}, rows
+	}
+
+	var columns []int
+	var header []string
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Field(i)
+		if !field.IsExported() || field.Tag.Get("table") == "-" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
+			continue
+		}
+		columns = append(columns, i)
+		header = append(header, field.Name)
+	}
+
+	rows := make([][]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		row := make([]string, 0, len(columns))
+		for _, c := range columns {
+			row = append(row, cellString(item.Field(c)))
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+func cellString(v reflect.Value) string {
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			parts = append(parts, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v.Interface())
+}
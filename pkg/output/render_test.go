@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSetFormat(t *testing.T) {
+	tests := []struct {
+		format, tmpl string
+		want         string
+		wantErr      bool
+	}{
+		{"", "", FormatTable, false},
+		{"json", "", FormatJSON, false},
+		{"yaml", "", FormatYAML, false},
+		{"csv", "", FormatCSV, false},
+		{"", "{{.Version}}", FormatTemplate, false},
+		{"table", "{{.Version}}", FormatTemplate, false},
+		{"xml", "", "", true},
+		{"template", "", "", true},
+		{"json", "{{.Version}}", "", true},
+		{"", "{{.Version", "", true},
+	}
+	for _, tt := range tests {
+		f := NewFormatter()
+		err := f.SetFormat(tt.format, tt.tmpl)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SetFormat(%q, %q) error = %v, wantErr %v", tt.format, tt.tmpl, err, tt.wantErr)
+			continue
+		}
+		if err == nil && f.Format() != tt.want {
+			t.Errorf("SetFormat(%q, %q) format = %q, want %q", tt.format, tt.tmpl, f.Format(), tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	items := []UploadItem{
+		{Profile: "prod", File: "app", Version: "v1.0.0-abc", Key: "v1.0.0-abc/app", URL: "https://example.com/app", Size: "1.0 KB", Status: "uploaded"},
+		{Profile: "test", File: "app", Version: "v1.0.0-abc", Status: "failed", Error: "access denied"},
+	}
+
+	tests := []struct {
+		format, tmpl string
+		contains     []string
+		excludes     []string
+	}{
+		{"table", "", []string{"PROFILE", "https://example.com/app", "access denied"}, []string{"KEY"}},
+		{"json", "", []string{`"Profile": "prod"`, `"Error": "access denied"`}, nil},
+		{"yaml", "", []string{"profile: prod", "status: failed"}, nil},
+		{"csv", "", []string{"Profile,File,Platform,Version,URL,Size,MD5,Status,Error\n", "test,app,,v1.0.0-abc,,,,failed,access denied\n"}, nil},
+		{"", "{{.Profile}} {{.URL}}", []string{"prod https://example.com/app\n", "test \n"}, nil},
+	}
+	for _, tt := range tests {
+		f := NewFormatter()
+		buf := &bytes.Buffer{}
+		f.SetOutput(buf)
+		if err := f.SetFormat(tt.format, tt.tmpl); err != nil {
+			t.Fatalf("SetFormat(%q) failed: %v", tt.format, err)
+		}
+		if err := f.Render(items); err != nil {
+			t.Fatalf("Render(%q) failed: %v", tt.format, err)
+		}
+		out := buf.String()
+		for _, s := range tt.contains {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s%s output missing %q:\n%s", tt.format, tt.tmpl, s, out)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(out, s) {
+				t.Errorf("%s output should not contain %q:\n%s", tt.format, s, out)
+			}
+		}
+	}
+}
+
+func TestRenderNestedAndSingle(t *testing.T) {
+	f := NewFormatter()
+	buf := &bytes.Buffer{}
+	f.SetOutput(buf)
+	f.SetFormat(FormatCSV, "")
+
+	groups := []VersionGroupItem{
+		{Profile: "prod", Version: "v1.0.0-abc", Filenames: []string{"a", "b"}, SizeBytes: 10, Files: []VersionItem{{Filename: "a"}}},
+	}
+	if err := f.Render(groups); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	header := strings.SplitN(buf.String(), "\n", 2)[0]
+	if header != "Profile,Version,Commit,FileCount,Size,Date,Filenames" {
+		t.Errorf("unexpected CSV header %q", header)
+	}
+	if !strings.Contains(buf.String(), `"a, b"`) {
+		t.Errorf("string slices should be joined:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	f.SetFormat("", "{{.Name}}/{{.Bucket}}")
+	if err := f.Render(ProfileItem{Name: "prod", Bucket: "releases"}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if buf.String() != "prod/releases\n" {
+		t.Errorf("single item template output = %q", buf.String())
+	}
+}
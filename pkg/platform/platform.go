@@ -0,0 +1,116 @@
+package platform
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Platform is a GOOS/GOARCH style target
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+var osAliases = map[string]string{
+	"linux":   "linux",
+	"darwin":  "darwin",
+	"macos":   "darwin",
+	"osx":     "darwin",
+	"mac":     "darwin",
+	"windows": "windows",
+	"win":     "windows",
+	"win32":   "windows",
+	"win64":   "windows",
+	"freebsd": "freebsd",
+}
+
+var archAliases = map[string]string{
+	"amd64":   "amd64",
+	"x86_64":  "amd64",
+	"x64":     "amd64",
+	"arm64":   "arm64",
+	"aarch64": "arm64",
+	"386":     "386",
+	"i386":    "386",
+	"i686":    "386",
+	"x86":     "386",
+	"arm":     "arm",
+	"armv7":   "arm",
+	"armhf":   "arm",
+}
+
+// tokenPattern splits paths into words; '_' is kept so x86_64 stays whole
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9_]+`)
+
+// Host returns the platform this binary runs on
+func Host() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// Parse parses "os/arch", accepting common aliases such as
+// macos/x86_64 or linux/aarch64. "auto" resolves to the host platform.
+func Parse(s string) (Platform, error) {
+	if s == "auto" {
+		return Host(), nil
+	}
+	parts := strings.Split(strings.ToLower(s), "/")
+	if len(parts) != 2 {
+		return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch (e.g. linux/amd64)", s)
+	}
+	goos, ok := osAliases[parts[0]]
+	if !ok {
+		return Platform{}, fmt.Errorf("unknown OS %q in platform %q", parts[0], s)
+	}
+	goarch, ok := archAliases[parts[1]]
+	if !ok {
+		return Platform{}, fmt.Errorf("unknown architecture %q in platform %q", parts[1], s)
+	}
+	return Platform{OS: goos, Arch: goarch}, nil
+}
+
+// Infer guesses the platform from a file path such as
+// "dist/myapp-linux-amd64", "myapp_Darwin_x86_64.tar.gz" or
+// "build/v1/windows/amd64/myapp.exe". The last OS and architecture words in
+// the path win; a ".exe" suffix implies windows.
+func Infer(path string) (Platform, bool) {
+	var p Platform
+	for _, word := range tokenPattern.FindAllString(filepath.ToSlash(path), -1) {
+		word = strings.ReplaceAll(strings.ToLower(word), "x86_64", "amd64")
+		if goos, ok := osAliases[word]; ok {
+			p.OS = goos
+			continue
+		}
+		if goarch, ok := archAliases[word]; ok {
+			p.Arch = goarch
+			continue
+		}
+		// Words like "linux_amd64" carry both parts
+		for _, part := range strings.Split(word, "_") {
+			if goos, ok := osAliases[part]; ok {
+				p.OS = goos
+			} else if goarch, ok := archAliases[part]; ok {
+				p.Arch = goarch
+			}
+		}
+	}
+	if p.OS == "" && strings.EqualFold(filepath.Ext(path), ".exe") {
+		p.OS = "windows"
+	}
+	if p.OS == "" || p.Arch == "" {
+		return Platform{}, false
+	}
+	return p, true
+}
+
+// String returns "os/arch"
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// IsZero reports whether the platform is unset
+func (p Platform) IsZero() bool {
+	return p.OS == "" && p.Arch == ""
+}
@@ -0,0 +1,57 @@
+package platform
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := map[string]Platform{
+		"linux/amd64":   {OS: "linux", Arch: "amd64"},
+		"macos/x86_64":  {OS: "darwin", Arch: "amd64"},
+		"Linux/aarch64": {OS: "linux", Arch: "arm64"},
+		"windows/386":   {OS: "windows", Arch: "386"},
+	}
+	for in, want := range tests {
+		got, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Parse(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	if p, _ := Parse("auto"); p != Host() {
+		t.Errorf("auto should resolve to host platform, got %v", p)
+	}
+
+	for _, in := range []string{"linux", "plan9/amd64", "linux/sparc", "linux/amd64/v2"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestInfer(t *testing.T) {
+	tests := map[string]Platform{
+		"dist/myapp-linux-amd64":             {OS: "linux", Arch: "amd64"},
+		"myapp_Darwin_x86_64.tar.gz":         {OS: "darwin", Arch: "amd64"},
+		"build/v1/windows/amd64/myapp.exe":   {OS: "windows", Arch: "amd64"},
+		"myapp-linux_arm64":                  {OS: "linux", Arch: "arm64"},
+		"obsput-v0.3.0-darwin-arm64.zip":     {OS: "darwin", Arch: "arm64"},
+		"release/myapp-aarch64-linux-gnu.gz": {OS: "linux", Arch: "arm64"},
+	}
+	for in, want := range tests {
+		got, ok := Infer(in)
+		if !ok {
+			t.Errorf("Infer(%q) found no platform", in)
+			continue
+		}
+		if got != want {
+			t.Errorf("Infer(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	if _, ok := Infer("myapp"); ok {
+		t.Error("plain file name should not infer a platform")
+	}
+}
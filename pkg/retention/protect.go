@@ -0,0 +1,83 @@
+package retention
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"obsput/pkg/obs"
+)
+
+// Protection lists versions that delete and prune must never remove.
+// Versions and tags are glob patterns as in path.Match; prefixes match the
+// key path above the version, e.g. "releases/stable".
+type Protection struct {
+	Versions []string `yaml:"versions,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+	Prefixes []string `yaml:"prefixes,omitempty"`
+}
+
+// Validate rejects malformed glob patterns
+func (p *Protection) Validate() error {
+	for _, pattern := range append(append([]string{}, p.Versions...), p.Tags...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	for _, prefix := range p.Prefixes {
+		if strings.Trim(prefix, "/") == "" {
+			return fmt.Errorf("empty prefix would protect every version")
+		}
+	}
+	return nil
+}
+
+// String describes the protection list, e.g. "versions=v1.*, prefixes=releases"
+func (p *Protection) String() string {
+	var rules []string
+	if len(p.Versions) > 0 {
+		rules = append(rules, "versions="+strings.Join(p.Versions, ","))
+	}
+	if len(p.Tags) > 0 {
+		rules = append(rules, "tags="+strings.Join(p.Tags, ","))
+	}
+	if len(p.Prefixes) > 0 {
+		rules = append(rules, "prefixes="+strings.Join(p.Prefixes, ","))
+	}
+	return strings.Join(rules, ", ")
+}
+
+// Protects returns why a version must be kept, or "" when it may be deleted.
+// A nil Protection protects nothing. tag is only called when tag patterns
+// are set; it may be nil otherwise.
+func (p *Protection) Protects(g obs.VersionGroup, tag func(obs.VersionGroup) (string, error)) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	for _, pattern := range p.Versions {
+		if ok, _ := path.Match(pattern, g.Version); ok {
+			return "protected version " + pattern, nil
+		}
+	}
+	for _, prefix := range p.Prefixes {
+		prefix = strings.Trim(prefix, "/") + "/"
+		if strings.HasPrefix(g.Prefix, prefix) {
+			return "protected prefix " + strings.TrimSuffix(prefix, "/"), nil
+		}
+	}
+	if len(p.Tags) > 0 && tag != nil {
+		name, err := tag(g)
+		if err != nil {
+			return "", fmt.Errorf("check tag of %s: %w", g.Prefix, err)
+		}
+		if name == "" {
+			return "", nil
+		}
+		for _, pattern := range p.Tags {
+			if ok, _ := path.Match(pattern, name); ok {
+				return "protected tag " + name, nil
+			}
+		}
+	}
+	return "", nil
+}
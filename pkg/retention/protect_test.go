@@ -0,0 +1,59 @@
+package retention
+
+import (
+	"errors"
+	"testing"
+
+	"obsput/pkg/obs"
+)
+
+func TestProtectionValidate(t *testing.T) {
+	if err := (&Protection{Versions: []string{"v1.["}}).Validate(); err == nil {
+		t.Error("malformed pattern should be rejected")
+	}
+	if err := (&Protection{Prefixes: []string{"/"}}).Validate(); err == nil {
+		t.Error("empty prefix should be rejected")
+	}
+	if err := (&Protection{Versions: []string{"v1.*"}, Tags: []string{"v*"}, Prefixes: []string{"releases"}}).Validate(); err != nil {
+		t.Errorf("valid protection rejected: %v", err)
+	}
+}
+
+func TestProtects(t *testing.T) {
+	p := &Protection{
+		Versions: []string{"v2.0.0-*"},
+		Tags:     []string{"v1.*"},
+		Prefixes: []string{"releases/stable"},
+	}
+	tags := map[string]string{"v1.0.0-c/": "v1.0.0", "v1.0.0-d/": "nightly"}
+	tag := func(g obs.VersionGroup) (string, error) { return tags[g.Prefix], nil }
+
+	tests := []struct {
+		group obs.VersionGroup
+		want  string
+	}{
+		{obs.VersionGroup{Version: "v2.0.0-a", Prefix: "v2.0.0-a/"}, "protected version v2.0.0-*"},
+		{obs.VersionGroup{Version: "v1.0.0-b", Prefix: "releases/stable/v1.0.0-b/"}, "protected prefix releases/stable"},
+		{obs.VersionGroup{Version: "v1.0.0-b", Prefix: "releases/stable-old/v1.0.0-b/"}, ""},
+		{obs.VersionGroup{Version: "v1.0.0-c", Prefix: "v1.0.0-c/"}, "protected tag v1.0.0"},
+		{obs.VersionGroup{Version: "v1.0.0-d", Prefix: "v1.0.0-d/"}, ""},
+	}
+	for _, tt := range tests {
+		got, err := p.Protects(tt.group, tag)
+		if err != nil {
+			t.Fatalf("Protects(%s) failed: %v", tt.group.Prefix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Protects(%s) = %q, want %q", tt.group.Prefix, got, tt.want)
+		}
+	}
+
+	var none *Protection
+	if got, _ := none.Protects(tests[0].group, nil); got != "" {
+		t.Errorf("nil protection should protect nothing, got %q", got)
+	}
+	failing := func(obs.VersionGroup) (string, error) { return "", errors.New("denied") }
+	if _, err := p.Protects(tests[4].group, failing); err == nil {
+		t.Error("expected tag lookup errors to be returned")
+	}
+}
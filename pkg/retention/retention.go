@@ -0,0 +1,126 @@
+package retention
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"obsput/pkg/obs"
+)
+
+// Policy decides which versions prune keeps. A version is kept when any rule
+// keeps it; everything else is deleted.
+type Policy struct {
+	// KeepLast keeps the newest N versions of each location
+	KeepLast int `yaml:"keep_last,omitempty"`
+	// KeepDays keeps versions uploaded less than D days ago
+	KeepDays int `yaml:"keep_days,omitempty"`
+	// KeepTagged keeps versions built from a git tag
+	KeepTagged bool `yaml:"keep_tagged,omitempty"`
+	// KeepMonthly keeps the newest version of each calendar month per location
+	KeepMonthly bool `yaml:"keep_monthly,omitempty"`
+}
+
+// Validate rejects negative counts and policies without any keep rule,
+// which would delete every version
+func (p *Policy) Validate() error {
+	if p.KeepLast < 0 || p.KeepDays < 0 {
+		return fmt.Errorf("keep_last and keep_days must not be negative")
+	}
+	if p.KeepLast == 0 && p.KeepDays == 0 && !p.KeepTagged && !p.KeepMonthly {
+		return fmt.Errorf("no keep rule set (keep_last, keep_days, keep_tagged or keep_monthly)")
+	}
+	return nil
+}
+
+// String describes the policy, e.g. "keep_last=5, keep_days=30"
+func (p *Policy) String() string {
+	var rules []string
+	if p.KeepLast > 0 {
+		rules = append(rules, fmt.Sprintf("keep_last=%d", p.KeepLast))
+	}
+	if p.KeepDays > 0 {
+		rules = append(rules, fmt.Sprintf("keep_days=%d", p.KeepDays))
+	}
+	if p.KeepTagged {
+		rules = append(rules, "keep_tagged")
+	}
+	if p.KeepMonthly {
+		rules = append(rules, "keep_monthly")
+	}
+	return strings.Join(rules, ", ")
+}
+
+// Decision is the verdict for one version
+type Decision struct {
+	Group obs.VersionGroup
+	// Location is the key path above the version, e.g. "releases" or
+	// "myapp/main"; keep_last and keep_monthly count per location
+	Location string
+	Keep     bool
+	// Reasons lists the rules that keep the version
+	Reasons []string
+}
+
+// Location returns the key path above a version directory, which holds the
+// prefix, project and branch segments of the key template
+func Location(g obs.VersionGroup) string {
+	dir := path.Dir(strings.TrimSuffix(g.Prefix, "/"))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// Plan applies the policy to versions. isTagged is only consulted for
+// versions no other rule keeps; it may be nil when KeepTagged is off.
+// Decisions are ordered by location, newest version first.
+func Plan(groups []obs.VersionGroup, p Policy, now time.Time, isTagged func(obs.VersionGroup) (bool, error)) ([]Decision, error) {
+	decisions := make([]Decision, 0, len(groups))
+	for _, g := range groups {
+		decisions = append(decisions, Decision{Group: g, Location: Location(g)})
+	}
+	sort.SliceStable(decisions, func(i, j int) bool {
+		if decisions[i].Location != decisions[j].Location {
+			return decisions[i].Location < decisions[j].Location
+		}
+		return decisions[i].Group.LastModified.After(decisions[j].Group.LastModified)
+	})
+
+	// Rank versions within each location, newest first
+	rank := make(map[string]int)
+	seenMonth := make(map[string]bool)
+	cutoff := now.AddDate(0, 0, -p.KeepDays)
+	for i := range decisions {
+		d := &decisions[i]
+		n := rank[d.Location]
+		rank[d.Location]++
+
+		if p.KeepLast > 0 && n < p.KeepLast {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("newest %d", p.KeepLast))
+		}
+		if p.KeepDays > 0 && d.Group.LastModified.After(cutoff) {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("younger than %dd", p.KeepDays))
+		}
+		if p.KeepMonthly {
+			month := d.Location + "\x00" + d.Group.LastModified.Format("2006-01")
+			if !seenMonth[month] {
+				seenMonth[month] = true
+				d.Reasons = append(d.Reasons, "newest of "+d.Group.LastModified.Format("2006-01"))
+			}
+		}
+		if p.KeepTagged && len(d.Reasons) == 0 && isTagged != nil {
+			tagged, err := isTagged(d.Group)
+			if err != nil {
+				return nil, fmt.Errorf("check tag of %s: %w", d.Group.Prefix, err)
+			}
+			if tagged {
+				d.Reasons = append(d.Reasons, "tagged")
+			}
+		}
+		d.Keep = len(d.Reasons) > 0
+	}
+	return decisions, nil
+}
@@ -0,0 +1,109 @@
+package retention
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"obsput/pkg/obs"
+)
+
+var now = time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+func group(prefix string, daysAgo int) obs.VersionGroup {
+	return obs.VersionGroup{Prefix: prefix, LastModified: now.AddDate(0, 0, -daysAgo)}
+}
+
+// kept returns the prefixes of kept versions in plan order
+func kept(decisions []Decision) []string {
+	var prefixes []string
+	for _, d := range decisions {
+		if d.Keep {
+			prefixes = append(prefixes, d.Group.Prefix)
+		}
+	}
+	return prefixes
+}
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (&Policy{}).Validate(); err == nil {
+		t.Error("empty policy should be rejected")
+	}
+	if err := (&Policy{KeepLast: -1, KeepTagged: true}).Validate(); err == nil {
+		t.Error("negative keep_last should be rejected")
+	}
+	if err := (&Policy{KeepMonthly: true}).Validate(); err != nil {
+		t.Errorf("keep_monthly alone should be valid: %v", err)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := map[string]string{
+		"v1.0.0-abc/":            "",
+		"releases/v1.0.0-abc/":   "releases",
+		"myapp/main/v1.0.0-abc/": "myapp/main",
+	}
+	for prefix, want := range tests {
+		if got := Location(obs.VersionGroup{Prefix: prefix}); got != want {
+			t.Errorf("Location(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	groups := []obs.VersionGroup{
+		group("main/v1-a/", 1),
+		group("main/v1-b/", 10),
+		group("main/v1-c/", 20),
+		group("main/v1-d/", 40),
+		group("dev/v1-e/", 30),
+		group("dev/v1-f/", 2),
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		tagged map[string]bool
+		want   []string
+	}{
+		{"keep last per location", Policy{KeepLast: 1}, nil, []string{"dev/v1-f/", "main/v1-a/"}},
+		{"keep days", Policy{KeepDays: 15}, nil, []string{"dev/v1-f/", "main/v1-a/", "main/v1-b/"}},
+		// now is 2026-03-15: 1, 10 days ago are March; 20 and 30 days ago February; 40 days ago early February
+		{"keep monthly", Policy{KeepMonthly: true}, nil, []string{"dev/v1-f/", "dev/v1-e/", "main/v1-a/", "main/v1-c/"}},
+		{"keep tagged", Policy{KeepTagged: true}, map[string]bool{"main/v1-d/": true}, []string{"main/v1-d/"}},
+		{"rules combine", Policy{KeepLast: 1, KeepTagged: true}, map[string]bool{"main/v1-c/": true}, []string{"dev/v1-f/", "main/v1-a/", "main/v1-c/"}},
+	}
+	for _, tt := range tests {
+		isTagged := func(g obs.VersionGroup) (bool, error) {
+			return tt.tagged[g.Prefix], nil
+		}
+		decisions, err := Plan(groups, tt.policy, now, isTagged)
+		if err != nil {
+			t.Fatalf("%s: Plan failed: %v", tt.name, err)
+		}
+		if got := kept(decisions); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: kept %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlanOnlyChecksTagsWhenNeeded(t *testing.T) {
+	groups := []obs.VersionGroup{group("v1-a/", 1), group("v1-b/", 2)}
+	var checked []string
+	isTagged := func(g obs.VersionGroup) (bool, error) {
+		checked = append(checked, g.Prefix)
+		return false, nil
+	}
+	if _, err := Plan(groups, Policy{KeepLast: 1, KeepTagged: true}, now, isTagged); err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if !reflect.DeepEqual(checked, []string{"v1-b/"}) {
+		t.Errorf("expected only the unkept version to be checked, got %v", checked)
+	}
+
+	failing := func(obs.VersionGroup) (bool, error) { return false, errors.New("denied") }
+	if _, err := Plan(groups, Policy{KeepTagged: true}, now, failing); err == nil {
+		t.Error("expected tag lookup errors to abort the plan")
+	}
+}
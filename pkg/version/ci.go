@@ -0,0 +1,119 @@
+package version
+
+import (
+	"os"
+	"strings"
+)
+
+// BuildInfo describes the CI build that produced an upload
+type BuildInfo struct {
+	Provider    string
+	Commit      string
+	Branch      string
+	BuildNumber string
+	PipelineURL string
+	Actor       string
+	// Tag is set when the build runs for a git tag
+	Tag string
+}
+
+// DetectBuildInfo reads build information from the CI environment.
+// Returns nil when no supported CI system is detected.
+func DetectBuildInfo() *BuildInfo {
+	return detectBuildInfo(os.Getenv)
+}
+
+func detectBuildInfo(getenv func(string) string) *BuildInfo {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		branch := getenv("GITHUB_HEAD_REF")
+		if branch == "" {
+			branch = getenv("GITHUB_REF_NAME")
+		}
+		tag := ""
+		if getenv("GITHUB_REF_TYPE") == "tag" {
+			tag = getenv("GITHUB_REF_NAME")
+		}
+		pipelineURL := ""
+		if getenv("GITHUB_RUN_ID") != "" {
+			pipelineURL = strings.TrimSuffix(getenv("GITHUB_SERVER_URL"), "/") + "/" +
+				getenv("GITHUB_REPOSITORY") + "/actions/runs/" + getenv("GITHUB_RUN_ID")
+		}
+		return &BuildInfo{
+			Provider:    "github",
+			Commit:      getenv("GITHUB_SHA"),
+			Branch:      branch,
+			BuildNumber: getenv("GITHUB_RUN_NUMBER"),
+			PipelineURL: pipelineURL,
+			Actor:       getenv("GITHUB_ACTOR"),
+			Tag:         tag,
+		}
+	case getenv("GITLAB_CI") == "true":
+		return &BuildInfo{
+			Provider:    "gitlab",
+			Commit:      getenv("CI_COMMIT_SHA"),
+			Branch:      getenv("CI_COMMIT_REF_NAME"),
+			BuildNumber: firstNonEmpty(getenv("CI_PIPELINE_IID"), getenv("CI_PIPELINE_ID")),
+			PipelineURL: getenv("CI_PIPELINE_URL"),
+			Actor:       getenv("GITLAB_USER_LOGIN"),
+			Tag:         getenv("CI_COMMIT_TAG"),
+		}
+	case getenv("JENKINS_URL") != "":
+		return &BuildInfo{
+			Provider:    "jenkins",
+			Commit:      getenv("GIT_COMMIT"),
+			Branch:      firstNonEmpty(getenv("BRANCH_NAME"), strings.TrimPrefix(getenv("GIT_BRANCH"), "origin/")),
+			BuildNumber: getenv("BUILD_NUMBER"),
+			PipelineURL: getenv("BUILD_URL"),
+			Actor:       firstNonEmpty(getenv("BUILD_USER_ID"), getenv("CHANGE_AUTHOR")),
+			Tag:         getenv("TAG_NAME"),
+		}
+	case getenv("DRONE") == "true":
+		return &BuildInfo{
+			Provider:    "drone",
+			Commit:      getenv("DRONE_COMMIT_SHA"),
+			Branch:      firstNonEmpty(getenv("DRONE_SOURCE_BRANCH"), getenv("DRONE_BRANCH")),
+			BuildNumber: getenv("DRONE_BUILD_NUMBER"),
+			PipelineURL: getenv("DRONE_BUILD_LINK"),
+			Actor:       getenv("DRONE_COMMIT_AUTHOR"),
+			Tag:         getenv("DRONE_TAG"),
+		}
+	}
+	return nil
+}
+
+// ShortCommit returns the commit abbreviated like `git rev-parse --short`
+func (b *BuildInfo) ShortCommit() string {
+	if len(b.Commit) > 7 {
+		return b.Commit[:7]
+	}
+	return b.Commit
+}
+
+// Metadata returns the non-empty fields as object metadata
+func (b *BuildInfo) Metadata() map[string]string {
+	metadata := make(map[string]string)
+	fields := map[string]string{
+		"ci-provider":     b.Provider,
+		"ci-commit":       b.Commit,
+		"ci-branch":       b.Branch,
+		"ci-build-number": b.BuildNumber,
+		"ci-pipeline-url": b.PipelineURL,
+		"ci-actor":        b.Actor,
+	}
+	for k, v := range fields {
+		if v != "" {
+			metadata[k] = v
+		}
+	}
+	return metadata
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
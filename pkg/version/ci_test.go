@@ -0,0 +1,114 @@
+package version
+
+import "testing"
+
+func envLookup(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestDetectBuildInfoNone(t *testing.T) {
+	if info := detectBuildInfo(envLookup(nil)); info != nil {
+		t.Errorf("expected nil outside CI, got %+v", info)
+	}
+}
+
+func TestDetectBuildInfoGitHub(t *testing.T) {
+	info := detectBuildInfo(envLookup(map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_SHA":        "0123456789abcdef",
+		"GITHUB_REF_NAME":   "main",
+		"GITHUB_RUN_NUMBER": "17",
+		"GITHUB_RUN_ID":     "99",
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_REPOSITORY": "cosysn/obsput",
+		"GITHUB_ACTOR":      "octocat",
+	}))
+	if info == nil {
+		t.Fatal("expected GitHub build info")
+	}
+	if info.Provider != "github" || info.Branch != "main" || info.BuildNumber != "17" || info.Actor != "octocat" {
+		t.Errorf("unexpected build info: %+v", info)
+	}
+	if info.PipelineURL != "https://github.com/cosysn/obsput/actions/runs/99" {
+		t.Errorf("unexpected pipeline URL: %s", info.PipelineURL)
+	}
+	if info.ShortCommit() != "0123456" {
+		t.Errorf("expected short commit 0123456, got %s", info.ShortCommit())
+	}
+}
+
+func TestDetectBuildInfoGitLab(t *testing.T) {
+	info := detectBuildInfo(envLookup(map[string]string{
+		"GITLAB_CI":          "true",
+		"CI_COMMIT_SHA":      "abcdef0123",
+		"CI_COMMIT_REF_NAME": "feature/x",
+		"CI_PIPELINE_IID":    "5",
+		"CI_PIPELINE_URL":    "https://gitlab.example.com/p/-/pipelines/1",
+		"GITLAB_USER_LOGIN":  "dev",
+	}))
+	if info == nil || info.Provider != "gitlab" {
+		t.Fatalf("expected GitLab build info, got %+v", info)
+	}
+	if info.Branch != "feature/x" || info.BuildNumber != "5" || info.Tag != "" {
+		t.Errorf("unexpected build info: %+v", info)
+	}
+}
+
+func TestDetectBuildInfoTag(t *testing.T) {
+	github := detectBuildInfo(envLookup(map[string]string{
+		"GITHUB_ACTIONS":  "true",
+		"GITHUB_REF_TYPE": "tag",
+		"GITHUB_REF_NAME": "v1.2.0",
+	}))
+	if github.Tag != "v1.2.0" {
+		t.Errorf("expected GitHub tag v1.2.0, got %q", github.Tag)
+	}
+	branch := detectBuildInfo(envLookup(map[string]string{
+		"GITHUB_ACTIONS":  "true",
+		"GITHUB_REF_TYPE": "branch",
+		"GITHUB_REF_NAME": "main",
+	}))
+	if branch.Tag != "" {
+		t.Errorf("branch builds should have no tag, got %q", branch.Tag)
+	}
+	gitlab := detectBuildInfo(envLookup(map[string]string{
+		"GITLAB_CI":     "true",
+		"CI_COMMIT_TAG": "v2.0.0",
+	}))
+	if gitlab.Tag != "v2.0.0" {
+		t.Errorf("expected GitLab tag v2.0.0, got %q", gitlab.Tag)
+	}
+}
+
+func TestDetectBuildInfoJenkinsAndDrone(t *testing.T) {
+	jenkins := detectBuildInfo(envLookup(map[string]string{
+		"JENKINS_URL":  "https://ci.example.com/",
+		"GIT_BRANCH":   "origin/release",
+		"BUILD_NUMBER": "12",
+	}))
+	if jenkins == nil || jenkins.Provider != "jenkins" || jenkins.Branch != "release" {
+		t.Errorf("unexpected Jenkins build info: %+v", jenkins)
+	}
+
+	drone := detectBuildInfo(envLookup(map[string]string{
+		"DRONE":              "true",
+		"DRONE_BRANCH":       "main",
+		"DRONE_BUILD_NUMBER": "3",
+	}))
+	if drone == nil || drone.Provider != "drone" || drone.BuildNumber != "3" {
+		t.Errorf("unexpected Drone build info: %+v", drone)
+	}
+}
+
+func TestBuildInfoMetadata(t *testing.T) {
+	info := &BuildInfo{Provider: "github", Branch: "main"}
+	metadata := info.Metadata()
+	if metadata["ci-branch"] != "main" {
+		t.Errorf("expected ci-branch main, got %q", metadata["ci-branch"])
+	}
+	if _, ok := metadata["ci-actor"]; ok {
+		t.Error("empty fields should not be included in metadata")
+	}
+}
@@ -3,31 +3,134 @@ package version
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
+
+	"obsput/pkg/layout"
 )
 
+// DefaultFormat is the version layout used by Generate.
+// Placeholders: {commit}, {date}, {time}, {counter} and {build}.
+const DefaultFormat = "v1.0.0-{commit}-{date}-{time}-{counter}"
+
+// BuildNumberSource hands out build numbers for the {build} placeholder
+type BuildNumberSource interface {
+	NextBuildNumber() (int64, error)
+}
+
 type Generator struct {
-	counter int64
+	counter   int64
+	source    BuildNumberSource
+	buildInfo *BuildInfo
 }
 
 func NewGenerator() *Generator {
-	return &Generator{}
+	return &Generator{
+		buildInfo: DetectBuildInfo(),
+	}
+}
+
+// BuildInfo returns the detected CI build information, or nil outside CI
+func (g *Generator) BuildInfo() *BuildInfo {
+	return g.buildInfo
+}
+
+// SetBuildNumberSource sets where {build} numbers come from
+func (g *Generator) SetBuildNumberSource(source BuildNumberSource) {
+	g.source = source
 }
 
 func (g *Generator) Generate() string {
-	commit := g.getShortCommit()
+	version, _ := g.GenerateFormat(DefaultFormat)
+	return version
+}
+
+// ValidateFormat rejects a format whose versions list, delete, download and
+// prune would not recognize as versions: they must start with "v" and
+// contain a "-", e.g. v{date}-{build}
+func ValidateFormat(format string) error {
+	sample := strings.NewReplacer(
+		"{commit}", "abc1234",
+		"{date}", "20060102",
+		"{time}", "150405",
+		"{counter}", "1",
+		"{build}", "1",
+	).Replace(format)
+	if !layout.LooksLikeVersion(sample) {
+		return fmt.Errorf("version format %q gives versions like %q, which are not recognized as versions: start it with 'v' and include a '-', e.g. v{date}-{build}", format, sample)
+	}
+	return nil
+}
+
+// GenerateFormat expands the placeholders in format.
+// {build} is only resolved when it appears, so the build number source is not
+// contacted for formats that do not use it.
+func (g *Generator) GenerateFormat(format string) (string, error) {
 	now := time.Now()
-	date := now.Format("20060102")
-	timestamp := now.Format("150405")
 	g.counter++
-	return fmt.Sprintf("v1.0.0-%s-%s-%s-%d", commit, date, timestamp, g.counter)
+
+	replacements := []string{
+		"{commit}", g.getShortCommit(),
+		"{date}", now.Format("20060102"),
+		"{time}", now.Format("150405"),
+		"{counter}", fmt.Sprintf("%d", g.counter),
+	}
+
+	if strings.Contains(format, "{build}") {
+		if g.source == nil {
+			return "", fmt.Errorf("version format uses {build} but no build number source is configured")
+		}
+		build, err := g.source.NextBuildNumber()
+		if err != nil {
+			return "", err
+		}
+		replacements = append(replacements, "{build}", strconv.FormatInt(build, 10))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(format), nil
+}
+
+// Branch returns the CI branch, or the current git branch outside CI
+func (g *Generator) Branch() string {
+	if g.buildInfo != nil && g.buildInfo.Branch != "" {
+		return g.buildInfo.Branch
+	}
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		// Detached checkout
+		return ""
+	}
+	return branch
+}
+
+// Tag returns the git tag being built: the CI tag, else a tag pointing
+// exactly at HEAD. Returns "" for untagged builds.
+func (g *Generator) Tag() string {
+	if g.buildInfo != nil && g.buildInfo.Tag != "" {
+		return g.buildInfo.Tag
+	}
+	cmd := exec.Command("git", "describe", "--tags", "--exact-match", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
 }
 
 func (g *Generator) getShortCommit() string {
 	cmd := exec.Command("git", "rev-parse", "--short", "HEAD")
 	output, err := cmd.Output()
 	if err != nil {
+		// Container builds often lack git; fall back to the CI-provided commit
+		if g.buildInfo != nil && g.buildInfo.Commit != "" {
+			return g.buildInfo.ShortCommit()
+		}
 		return "unknown"
 	}
 	return strings.TrimSpace(string(output))
@@ -61,3 +61,48 @@ func TestVersionConsistency(t *testing.T) {
 		t.Error("different calls should produce different versions")
 	}
 }
+
+type stubBuildNumberSource struct {
+	next int64
+}
+
+func (s *stubBuildNumberSource) NextBuildNumber() (int64, error) {
+	s.next++
+	return s.next, nil
+}
+
+func TestGenerateFormatBuildNumber(t *testing.T) {
+	g := NewGenerator()
+	g.SetBuildNumberSource(&stubBuildNumberSource{next: 41})
+
+	version, err := g.GenerateFormat("v2.0.0-{date}-{build}")
+	if err != nil {
+		t.Fatalf("GenerateFormat failed: %v", err)
+	}
+	if !strings.HasSuffix(version, "-42") {
+		t.Errorf("expected build number 42 in version, got '%s'", version)
+	}
+	if strings.Contains(version, "{") {
+		t.Errorf("unexpanded placeholder in version '%s'", version)
+	}
+}
+
+func TestGenerateFormatBuildNumberWithoutSource(t *testing.T) {
+	g := NewGenerator()
+	if _, err := g.GenerateFormat("v1.0.0-{build}"); err == nil {
+		t.Error("expected error when {build} is used without a source")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{DefaultFormat, "v2.0.0-{date}-{build}", "v{date}-{counter}"} {
+		if err := ValidateFormat(format); err != nil {
+			t.Errorf("ValidateFormat(%q) failed: %v", format, err)
+		}
+	}
+	for _, format := range []string{"{build}", "{date}.{counter}", "v{build}", "release-{build}"} {
+		if err := ValidateFormat(format); err == nil {
+			t.Errorf("ValidateFormat(%q) should fail", format)
+		}
+	}
+}